@@ -224,13 +224,13 @@ async function saveStack() {
     }
 }
 
-async function performAction(action) {
+async function performAction(action, params = '') {
     if (!currentStack) return;
     const btn = document.getElementById('toggle-btn');
     const originalContent = btn.innerHTML;
     btn.innerHTML = '<i class="fas fa-circle-notch fa-spin text-[10px]"></i> WAIT';
     
-    await fetch(`/api/stack/action?name=${currentStack}&action=${action}`, { method: 'POST' });
+    await fetch(`/api/stack/action?name=${currentStack}&action=${action}${params}`, { method: 'POST' });
     updateStatus();
 }
 
@@ -239,6 +239,13 @@ function toggleStack() {
     performAction(status.toLowerCase().startsWith('operational') ? 'stop' : 'start');
 }
 
+function downStack() {
+    if (!currentStack) return;
+    if (!confirm(`Remove all containers and networks of stack '${currentStack}'?`)) return;
+    const removeVolumes = confirm('Also remove the volumes of this stack? Their data will be lost.');
+    performAction('down', removeVolumes ? '&volumes=true' : '');
+}
+
 function startLogs() {
     const logsContent = document.getElementById('logs-content');
     const select = document.getElementById('logs-container-select');
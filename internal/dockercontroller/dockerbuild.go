@@ -0,0 +1,229 @@
+package dockercontroller
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+const inlineDockerfileName = ".bunshin.Dockerfile"
+
+func serviceImageName(stackName string, svc types.ServiceConfig) string {
+	if svc.Image != "" {
+		return svc.Image
+	}
+	return fmt.Sprintf("%s-%s", stackName, svc.Name)
+}
+
+func (c *Controller) BuildService(ctx context.Context, stackName string, svc types.ServiceConfig, pull bool, out io.Writer) error {
+	if svc.Build == nil {
+		return fmt.Errorf("service '%s' has no build section", svc.Name)
+	}
+	if out == nil {
+		out = io.Discard
+	}
+	b := svc.Build
+	tag := serviceImageName(stackName, svc)
+	labels := map[string]string{}
+	maps.Copy(labels, b.Labels)
+	labels["bunshin.stack"] = stackName
+	labels["bunshin.service"] = svc.Name
+	opts := build.ImageBuildOptions{
+		Tags:        append([]string{tag}, b.Tags...),
+		Dockerfile:  b.Dockerfile,
+		BuildArgs:   b.Args,
+		Target:      b.Target,
+		Labels:      labels,
+		PullParent:  pull || b.Pull,
+		NoCache:     b.NoCache,
+		Remove:      true,
+		NetworkMode: b.Network,
+		ExtraHosts:  b.ExtraHosts.AsList(":"),
+		ShmSize:     int64(b.ShmSize),
+		CacheFrom:   b.CacheFrom,
+		Platform:    svc.Platform,
+	}
+	var buildContext io.Reader
+	if isRemoteContext(b.Context) {
+		if b.DockerfileInline != "" {
+			return fmt.Errorf("service '%s': dockerfile_inline is not supported with a remote build context", svc.Name)
+		}
+		opts.RemoteContext = b.Context
+	} else {
+		extra := map[string]string{}
+		if b.DockerfileInline != "" {
+			extra[inlineDockerfileName] = b.DockerfileInline
+			opts.Dockerfile = inlineDockerfileName
+		}
+		if _, err := os.Stat(b.Context); err != nil {
+			return fmt.Errorf("build context for '%s' not available: %w", svc.Name, err)
+		}
+		excludes, err := contextExcludes(b.Context, b.Dockerfile)
+		if err != nil {
+			return fmt.Errorf("failed to read .dockerignore for '%s': %w", svc.Name, err)
+		}
+		archive := archiveContext(b.Context, excludes, extra)
+		defer archive.Close()
+		buildContext = archive
+	}
+	log.Printf("[BUILD] Building image '%s' for service '%s' from '%s'", tag, svc.Name, b.Context)
+	resp, err := c.cli.ImageBuild(ctx, buildContext, opts)
+	if err != nil {
+		return fmt.Errorf("failed to build image '%s': %w", tag, err)
+	}
+	defer resp.Body.Close()
+	if err := streamProgress(resp.Body, out, "[BUILD]"); err != nil {
+		return fmt.Errorf("failed to build image '%s': %w", tag, err)
+	}
+	log.Printf("[BUILD] Successfully built image '%s'", tag)
+	return nil
+}
+
+func streamProgress(body io.Reader, out io.Writer, prefix string) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			fmt.Fprintln(out, msg.Error.Message)
+			return msg.Error
+		}
+		if msg.ProgressMessage != "" {
+			continue
+		}
+		line := msg.Stream
+		if line == "" && msg.Status != "" {
+			line = strings.TrimSpace(msg.ID+" "+msg.Status) + "\n"
+		}
+		if line == "" {
+			continue
+		}
+		io.WriteString(out, line)
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			log.Printf("%s %s", prefix, trimmed)
+		}
+	}
+}
+
+func isRemoteContext(buildContext string) bool {
+	for _, prefix := range []string{"http://", "https://", "git://", "git@", "github.com/"} {
+		if strings.HasPrefix(buildContext, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func contextExcludes(dir string, dockerfile string) (*patternmatcher.PatternMatcher, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	// Like the docker CLI, always send the Dockerfile and .dockerignore to the daemon
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if filepath.IsLocal(dockerfile) {
+		patterns = append(patterns, "!"+dockerfile)
+	}
+	patterns = append(patterns, "!.dockerignore")
+	return patternmatcher.New(patterns)
+}
+
+func archiveContext(dir string, excludes *patternmatcher.PatternMatcher, extra map[string]string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		parents := map[string]patternmatcher.MatchInfo{}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil || rel == "." {
+				return err
+			}
+			if excludes != nil {
+				excluded, match, err := excludes.MatchesUsingParentResults(rel, parents[filepath.Dir(rel)])
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					parents[rel] = match
+				}
+				if excluded {
+					if info.IsDir() && !excludes.Exclusions() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+			link := ""
+			if info.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			}
+			hdr, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+			if info.IsDir() {
+				hdr.Name += "/"
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		for name, content := range extra {
+			if err != nil {
+				break
+			}
+			if err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err == nil {
+				_, err = io.WriteString(tw, content)
+			}
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,56 @@
+package dockercontroller
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestArchiveContextDockerignore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".dockerignore":     "node_modules\n*.log\nsecrets/\n!keep.log\nDockerfile\n",
+		"Dockerfile":        "FROM scratch\n",
+		"main.go":           "package main\n",
+		"debug.log":         "noise",
+		"keep.log":          "kept",
+		"node_modules/a.js": "x",
+		"secrets/key":       "x",
+		"src/app.go":        "package app\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excludes, err := contextExcludes(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := archiveContext(dir, excludes, map[string]string{inlineDockerfileName: "FROM scratch\n"})
+	defer archive.Close()
+	names := []string{}
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	slices.Sort(names)
+	want := []string{".bunshin.Dockerfile", ".dockerignore", "Dockerfile", "keep.log", "main.go", "src/", "src/app.go"}
+	if !slices.Equal(names, want) {
+		t.Errorf("archive contents = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,187 @@
+package dockercontroller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/tanq16/bunshin/internal/stackmanager"
+)
+
+func buildHealthcheck(hc *types.HealthCheckConfig) *container.HealthConfig {
+	if hc == nil {
+		return nil
+	}
+	if hc.Disable || (len(hc.Test) > 0 && hc.Test[0] == "NONE") {
+		return &container.HealthConfig{Test: []string{"NONE"}}
+	}
+	health := &container.HealthConfig{}
+	if len(hc.Test) > 0 {
+		health.Test = []string(hc.Test)
+	}
+	if hc.Interval != nil {
+		health.Interval = time.Duration(*hc.Interval)
+	}
+	if hc.Timeout != nil {
+		health.Timeout = time.Duration(*hc.Timeout)
+	}
+	if hc.StartPeriod != nil {
+		health.StartPeriod = time.Duration(*hc.StartPeriod)
+	}
+	if hc.StartInterval != nil {
+		health.StartInterval = time.Duration(*hc.StartInterval)
+	}
+	if hc.Retries != nil {
+		health.Retries = int(*hc.Retries)
+	}
+	return health
+}
+
+func buildResources(svc types.ServiceConfig) container.Resources {
+	resources := container.Resources{
+		CPUShares:          svc.CPUShares,
+		CpusetCpus:         svc.CPUSet,
+		CPUPeriod:          svc.CPUPeriod,
+		CPUQuota:           svc.CPUQuota,
+		CPURealtimePeriod:  svc.CPURTPeriod,
+		CPURealtimeRuntime: svc.CPURTRuntime,
+		CgroupParent:       svc.CgroupParent,
+		Devices:            buildDevices(svc.Devices),
+		DeviceCgroupRules:  svc.DeviceCgroupRules,
+	}
+	if svc.Deploy != nil {
+		if limits := svc.Deploy.Resources.Limits; limits != nil {
+			resources.NanoCPUs = int64(float64(limits.NanoCPUs) * 1e9)
+			resources.Memory = int64(limits.MemoryBytes)
+			if limits.Pids > 0 {
+				pids := limits.Pids
+				resources.PidsLimit = &pids
+			}
+		}
+		if reservations := svc.Deploy.Resources.Reservations; reservations != nil {
+			resources.MemoryReservation = int64(reservations.MemoryBytes)
+		}
+	}
+	if svc.CPUS > 0 {
+		resources.NanoCPUs = int64(float64(svc.CPUS) * 1e9)
+	}
+	if svc.MemLimit > 0 {
+		resources.Memory = int64(svc.MemLimit)
+	}
+	if svc.MemReservation > 0 {
+		resources.MemoryReservation = int64(svc.MemReservation)
+	}
+	if svc.MemSwapLimit != 0 {
+		resources.MemorySwap = int64(svc.MemSwapLimit)
+	}
+	if svc.MemSwappiness != 0 {
+		swappiness := int64(svc.MemSwappiness)
+		resources.MemorySwappiness = &swappiness
+	}
+	if svc.PidsLimit != 0 {
+		pids := svc.PidsLimit
+		resources.PidsLimit = &pids
+	}
+	if svc.OomKillDisable {
+		resources.OomKillDisable = &svc.OomKillDisable
+	}
+	for _, name := range slices.Sorted(maps.Keys(svc.Ulimits)) {
+		u := svc.Ulimits[name]
+		soft, hard := int64(u.Soft), int64(u.Hard)
+		if u.Single != 0 {
+			soft, hard = int64(u.Single), int64(u.Single)
+		}
+		resources.Ulimits = append(resources.Ulimits, &container.Ulimit{Name: name, Soft: soft, Hard: hard})
+	}
+	return resources
+}
+
+func buildStopTimeout(gracePeriod *types.Duration) *int {
+	if gracePeriod == nil {
+		return nil
+	}
+	seconds := int(time.Duration(*gracePeriod).Seconds())
+	return &seconds
+}
+
+func buildLabels(stackName string, svc types.ServiceConfig) map[string]string {
+	reserved := map[string]string{"bunshin.stack": stackName, "bunshin.service": svc.Name, "bunshin.managed": "true"}
+	labels := make(map[string]string, len(svc.Labels)+len(svc.CustomLabels)+len(reserved))
+	for _, src := range []types.Labels{svc.Labels, svc.CustomLabels} {
+		for k, v := range src {
+			if strings.HasPrefix(k, "bunshin.") {
+				if reserved[k] != v {
+					log.Printf("[SERVICE] Ignoring reserved label '%s' on service '%s'", k, svc.Name)
+				}
+				continue
+			}
+			labels[k] = v
+		}
+	}
+	maps.Copy(labels, reserved)
+	return labels
+}
+
+func namespaceMode(naming stackmanager.Naming, project *types.Project, stackName string, mode string) string {
+	if serviceName, ok := strings.CutPrefix(mode, "service:"); ok {
+		return "container:" + naming.ServiceContainerName(project, stackName, serviceName)
+	}
+	return mode
+}
+
+func buildDevices(devices []types.DeviceMapping) []container.DeviceMapping {
+	mappings := make([]container.DeviceMapping, 0, len(devices))
+	for _, d := range devices {
+		target := d.Target
+		if target == "" {
+			target = d.Source
+		}
+		permissions := d.Permissions
+		if permissions == "" {
+			permissions = "rwm"
+		}
+		mappings = append(mappings, container.DeviceMapping{PathOnHost: d.Source, PathInContainer: target, CgroupPermissions: permissions})
+	}
+	return mappings
+}
+
+func buildEnv(svc types.ServiceConfig, stackEnv map[string]string) []string {
+	env := map[string]string{}
+	for k, v := range svc.Environment {
+		if v != nil {
+			env[k] = *v
+		} else if val, exists := stackEnv[k]; exists {
+			env[k] = val
+		}
+	}
+	envList := []string{}
+	for k, v := range env {
+		envList = append(envList, fmt.Sprintf("%s=%s", k, v))
+	}
+	slices.Sort(envList)
+	return envList
+}
+
+func buildConfigHash(config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform string, imageID string) (string, error) {
+	data, err := json.Marshal(struct {
+		Config     *container.Config
+		HostConfig *container.HostConfig
+		Networking *network.NetworkingConfig
+		Platform   string
+		ImageID    string
+	}{config, hostConfig, networkingConfig, platform, imageID})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
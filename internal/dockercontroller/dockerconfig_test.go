@@ -0,0 +1,155 @@
+package dockercontroller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/tanq16/bunshin/internal/stackmanager"
+)
+
+func TestNamespaceModeService(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"db":  {Name: "db"},
+		"app": {Name: "app", Pid: "service:db", Ipc: "service:db"},
+	}}
+	app := project.Services["app"]
+	if got := namespaceMode(stackmanager.NewNaming(false), project, "web", app.Pid); got != "container:web_db_1" {
+		t.Errorf("pid: got %q, want %q", got, "container:web_db_1")
+	}
+	if got := namespaceMode(stackmanager.NewNaming(false), project, "web", app.Ipc); got != "container:web_db_1" {
+		t.Errorf("ipc: got %q, want %q", got, "container:web_db_1")
+	}
+}
+
+func TestNamespaceModePassthrough(t *testing.T) {
+	project := &types.Project{Services: types.Services{}}
+	for _, mode := range []string{"", "host", "private", "shareable", "container:other"} {
+		if got := namespaceMode(stackmanager.NewNaming(false), project, "web", mode); got != mode {
+			t.Errorf("mode %q: got %q", mode, got)
+		}
+	}
+}
+
+func TestNamespaceModeComposeNames(t *testing.T) {
+	project := &types.Project{Services: types.Services{"db": {Name: "db"}}}
+	if got := namespaceMode(stackmanager.NewNaming(true), project, "web", "service:db"); got != "container:web-db-1" {
+		t.Errorf("got %q, want %q", got, "container:web-db-1")
+	}
+}
+
+func hashService(t *testing.T, svc types.ServiceConfig, stackEnv map[string]string, imageID string) string {
+	t.Helper()
+	project := &types.Project{Name: "web", Services: types.Services{svc.Name: svc}}
+	exposedPorts, portBindings, err := buildPorts(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mounts, binds := buildMounts(project, svc)
+	config := &container.Config{
+		Image:        svc.Image,
+		Env:          buildEnv(svc, stackEnv),
+		ExposedPorts: exposedPorts,
+		Labels:       buildLabels("web", svc),
+	}
+	hostConfig := &container.HostConfig{
+		Binds:        binds,
+		Mounts:       mounts,
+		PortBindings: portBindings,
+		Sysctls:      svc.Sysctls,
+	}
+	hash, err := buildConfigHash(config, hostConfig, nil, svc.Platform, imageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func baseHashService() types.ServiceConfig {
+	env := types.MappingWithEquals{}
+	labels := types.Labels{}
+	sysctls := types.Mapping{}
+	for i := range 20 {
+		value := fmt.Sprintf("v%d", i)
+		env[fmt.Sprintf("VAR_%d", i)] = &value
+		labels[fmt.Sprintf("label.%d", i)] = value
+		sysctls[fmt.Sprintf("net.core.opt%d", i)] = value
+	}
+	env["FROM_STACK"] = nil
+	return types.ServiceConfig{
+		Name:        "app",
+		Image:       "nginx:latest",
+		Environment: env,
+		Labels:      labels,
+		Sysctls:     sysctls,
+		Ports: []types.ServicePortConfig{
+			{Target: 80, Published: "8080", Protocol: "tcp"},
+			{Target: 443, Published: "8443", Protocol: "tcp"},
+		},
+		Volumes: []types.ServiceVolumeConfig{
+			{Type: types.VolumeTypeBind, Source: "/srv/html", Target: "/usr/share/nginx/html"},
+		},
+	}
+}
+
+func TestConfigHashStable(t *testing.T) {
+	stackEnv := map[string]string{"FROM_STACK": "stack"}
+	want := hashService(t, baseHashService(), stackEnv, "sha256:aaa")
+	// Rebuilding the maps changes their iteration order
+	for range 20 {
+		if got := hashService(t, baseHashService(), stackEnv, "sha256:aaa"); got != want {
+			t.Fatalf("hash changed between identical builds: %s != %s", got, want)
+		}
+	}
+}
+
+func TestConfigHashChanges(t *testing.T) {
+	stackEnv := map[string]string{"FROM_STACK": "stack"}
+	base := hashService(t, baseHashService(), stackEnv, "sha256:aaa")
+	tests := []struct {
+		name     string
+		mutate   func(svc *types.ServiceConfig)
+		stackEnv map[string]string
+		imageID  string
+	}{
+		{name: "env value", mutate: func(svc *types.ServiceConfig) {
+			value := "changed"
+			svc.Environment["VAR_3"] = &value
+		}},
+		{name: "env added", mutate: func(svc *types.ServiceConfig) {
+			value := "new"
+			svc.Environment["NEW_VAR"] = &value
+		}},
+		{name: "stack env", stackEnv: map[string]string{"FROM_STACK": "other"}},
+		{name: "published port", mutate: func(svc *types.ServiceConfig) { svc.Ports[0].Published = "9090" }},
+		{name: "port added", mutate: func(svc *types.ServiceConfig) {
+			svc.Ports = append(svc.Ports, types.ServicePortConfig{Target: 53, Published: "53", Protocol: "udp"})
+		}},
+		{name: "bind source", mutate: func(svc *types.ServiceConfig) { svc.Volumes[0].Source = "/srv/other" }},
+		{name: "bind read only", mutate: func(svc *types.ServiceConfig) { svc.Volumes[0].ReadOnly = true }},
+		{name: "volume added", mutate: func(svc *types.ServiceConfig) {
+			svc.Volumes = append(svc.Volumes, types.ServiceVolumeConfig{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"})
+		}},
+		{name: "image digest", imageID: "sha256:bbb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := baseHashService()
+			if tt.mutate != nil {
+				tt.mutate(&svc)
+			}
+			env := stackEnv
+			if tt.stackEnv != nil {
+				env = tt.stackEnv
+			}
+			imageID := "sha256:aaa"
+			if tt.imageID != "" {
+				imageID = tt.imageID
+			}
+			if got := hashService(t, svc, env, imageID); got == base {
+				t.Errorf("hash did not change")
+			}
+		})
+	}
+}
@@ -2,35 +2,36 @@ package dockercontroller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"maps"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 	"github.com/gorilla/websocket"
 	"github.com/tanq16/bunshin/internal/stackmanager"
 )
 
 type Controller struct {
-	cli *client.Client
+	cli    *client.Client
+	naming stackmanager.Naming
 }
 
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-func New(cli *client.Client) *Controller {
-	return &Controller{cli: cli}
+func New(cli *client.Client, naming stackmanager.Naming) *Controller {
+	return &Controller{cli: cli, naming: naming}
 }
 
 func (c *Controller) ResolveNetworkName(ctx context.Context, networkName string) (string, error) {
@@ -96,218 +97,330 @@ func (c *Controller) GetStatus(name string) string {
 	return status
 }
 
-func (c *Controller) GetContainerInstanceNumber(ctx context.Context, stackName, serviceName string) int {
-	f := filters.NewArgs()
-	f.Add("label", "bunshin.stack="+stackName)
-	f.Add("label", "bunshin.service="+serviceName)
-	containers, _ := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
-	return len(containers) + 1
-}
-
-func (c *Controller) StopStack(ctx context.Context, name string) error {
-	log.Printf("[STOP] Stopping stack '%s'", name)
+func (c *Controller) DownStack(ctx context.Context, name string, removeVolumes bool) error {
+	log.Printf("[DOWN] Taking down stack '%s'", name)
 	f := filters.NewArgs()
 	f.Add("label", "bunshin.stack="+name)
 	containers, _ := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
-	log.Printf("[STOP] Found %d container(s) to stop for stack '%s'", len(containers), name)
+	log.Printf("[DOWN] Found %d container(s) to remove for stack '%s'", len(containers), name)
 	for _, ctr := range containers {
-		log.Printf("[STOP] Stopping container '%s' (ID: %s)", ctr.Names[0], ctr.ID[:12])
+		log.Printf("[DOWN] Stopping container '%s' (ID: %s)", ctr.Names[0], ctr.ID[:12])
 		if err := c.cli.ContainerStop(ctx, ctr.ID, container.StopOptions{}); err != nil {
-			log.Printf("[STOP] Error stopping container '%s': %v", ctr.Names[0], err)
+			log.Printf("[DOWN] Error stopping container '%s': %v", ctr.Names[0], err)
 		}
-		log.Printf("[STOP] Removing container '%s'", ctr.Names[0])
+		log.Printf("[DOWN] Removing container '%s'", ctr.Names[0])
 		if err := c.cli.ContainerRemove(ctx, ctr.ID, container.RemoveOptions{Force: true}); err != nil {
-			log.Printf("[STOP] Error removing container '%s': %v", ctr.Names[0], err)
+			log.Printf("[DOWN] Error removing container '%s': %v", ctr.Names[0], err)
 		} else {
-			log.Printf("[STOP] Successfully removed container '%s'", ctr.Names[0])
+			log.Printf("[DOWN] Successfully removed container '%s'", ctr.Names[0])
 		}
 	}
-	log.Printf("[STOP] Stack '%s' stopped successfully", name)
+	if err := c.RemoveNetworks(ctx, name); err != nil {
+		log.Printf("[DOWN] Error removing networks for stack '%s': %v", name, err)
+	}
+	if removeVolumes {
+		log.Printf("[DOWN] Removing volumes for stack '%s'", name)
+		if err := c.RemoveVolumes(ctx, name); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DOWN] Stack '%s' taken down successfully", name)
 	return nil
 }
 
-func (c *Controller) StartStack(ctx context.Context, name string, project *types.Project, isUpdate bool, stackEnv map[string]string) error {
+func (c *Controller) StackAction(ctx context.Context, name string, project *types.Project, action string, signal string) error {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+name)
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	if len(containers) == 0 {
+		log.Printf("[ACTION] Stack '%s' has no containers, nothing to %s", name, action)
+		return nil
+	}
+	// Dependencies go first for restart/unpause, dependents first for everything else
+	if project != nil {
+		order := map[string]int{}
+		for i, svc := range stackmanager.SortServicesByDependencies(project.Services) {
+			order[svc.Name] = i
+		}
+		slices.SortStableFunc(containers, func(a, b container.Summary) int {
+			return order[a.Labels["bunshin.service"]] - order[b.Labels["bunshin.service"]]
+		})
+		if action != "restart" && action != "unpause" {
+			slices.Reverse(containers)
+		}
+	}
+	log.Printf("[ACTION] Running '%s' on %d container(s) of stack '%s'", action, len(containers), name)
+	var errs []error
+	for _, ctr := range containers {
+		if err := c.containerAction(ctx, ctr, action, signal); err != nil {
+			log.Printf("[ACTION] %v", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Controller) StartStack(ctx context.Context, name string, project *types.Project, isUpdate bool, removeOrphans bool, stackEnv map[string]string, out io.Writer) ([]string, error) {
 	log.Printf("[START] Starting stack '%s' with %d service(s)", name, len(project.Services))
+	orphans, err := c.handleOrphans(ctx, name, project, removeOrphans, out)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.EnsureNetworks(ctx, name, project); err != nil {
+		return orphans, err
+	}
+	if err := c.EnsureVolumes(ctx, name, project); err != nil {
+		return orphans, err
+	}
 	// Sort services by dependencies so dependencies are started first
 	sortedServices := stackmanager.SortServicesByDependencies(project.Services)
+	recreated := map[string]bool{}
 	for _, svc := range sortedServices {
 		log.Printf("[SERVICE] Processing service '%s' from stack '%s'", svc.Name, name)
-		log.Printf("[SERVICE] Image: %s", svc.Image)
-		if isUpdate {
-			log.Printf("[UPDATE] Pulling latest image for '%s'", svc.Image)
-			out, err := c.cli.ImagePull(ctx, svc.Image, image.PullOptions{})
-			if err != nil {
-				log.Printf("[UPDATE] Error pulling image '%s': %v", svc.Image, err)
-			} else {
-				io.Copy(io.Discard, out)
-				out.Close()
-				log.Printf("[UPDATE] Successfully pulled image '%s'", svc.Image)
-			}
-		}
-
-		if err := stackmanager.WaitForDependencies(ctx, c.cli, project, name, svc); err != nil {
-			log.Printf("[ERROR] Dependency check failed for '%s': %v", svc.Name, err)
+		log.Printf("[SERVICE] Image: %s", serviceImageName(name, svc))
+		if err := c.prepareImage(ctx, name, svc, isUpdate, out); err != nil {
+			log.Printf("[ERROR] Skipping service '%s': %v", svc.Name, err)
 			continue
 		}
-		cName := svc.ContainerName
-		if cName == "" {
-			instanceNum := c.GetContainerInstanceNumber(ctx, name, svc.Name)
-			cName = fmt.Sprintf("%s_%s_%d", name, svc.Name, instanceNum)
-		}
-		log.Printf("[SERVICE] Container name: %s", cName)
 
-		exposedPorts := nat.PortSet{}
-		portBindings := nat.PortMap{}
-		for _, p := range svc.Ports {
-			protocol := p.Protocol
-			if protocol == "" {
-				protocol = "tcp"
-			}
-			port := nat.Port(fmt.Sprintf("%d/%s", p.Target, protocol))
-			exposedPorts[port] = struct{}{}
-			if p.Published != "" {
-				hostIP := p.HostIP
-				if hostIP == "" {
-					hostIP = "0.0.0.0"
-				}
-				portBindings[port] = []nat.PortBinding{{HostIP: hostIP, HostPort: p.Published}}
-				log.Printf("[SERVICE] Mapping port %s:%d", p.Published, p.Target)
+		force := false
+		for dep, cfg := range svc.DependsOn {
+			if cfg.Restart && recreated[dep] {
+				log.Printf("[SERVICE] Dependency '%s' of service '%s' was recreated", dep, svc.Name)
+				force = true
 			}
 		}
-
-		binds := []string{}
-		for _, v := range svc.Volumes {
-			if v.Type == "bind" || v.Type == "" {
-				bind := fmt.Sprintf("%s:%s", v.Source, v.Target)
-				if v.ReadOnly {
-					bind += ":ro"
-				}
-				binds = append(binds, bind)
-			}
+		changed, err := c.startService(ctx, name, project, svc, stackEnv, force)
+		if err != nil {
+			log.Printf("[ERROR] Skipping service '%s': %v", svc.Name, err)
 		}
-		if len(binds) > 0 {
-			log.Printf("[SERVICE] Mounting %d volume(s)", len(binds))
+		recreated[svc.Name] = changed
+	}
+	if isUpdate {
+		log.Printf("[UPDATE] Pruning dangling images for stack '%s'", name)
+		pf := filters.NewArgs()
+		pf.Add("dangling", "true")
+		pruneReport, err := c.cli.ImagesPrune(ctx, pf)
+		if err != nil {
+			log.Printf("[UPDATE] Error pruning images: %v", err)
+		} else {
+			log.Printf("[UPDATE] Reclaimed %d bytes from dangling images", pruneReport.SpaceReclaimed)
 		}
-		networkMode := ""
-		var networkingConfig *network.NetworkingConfig
+	}
+	return orphans, nil
+}
 
-		if svc.NetworkMode != "" {
-			networkMode = svc.NetworkMode
-			specialModes := []string{"host", "bridge", "none"}
-			isSpecialMode := slices.Contains(specialModes, networkMode)
-			if after, ok := strings.CutPrefix(networkMode, "service:"); ok {
-				depService := stackmanager.FindService(project, after)
-				if depService != nil && depService.ContainerName != "" {
-					networkMode = "container:" + depService.ContainerName
-				} else {
-					networkMode = fmt.Sprintf("container:%s_%s_1", name, after)
-				}
-				log.Printf("[SERVICE] Resolved network_mode to: %s", networkMode)
-			} else if !isSpecialMode && !strings.HasPrefix(networkMode, "container:") {
-				// Single named network in network_mode
-				resolvedNetwork, err := c.ResolveNetworkName(ctx, networkMode)
-				if err != nil {
-					log.Printf("[ERROR] Failed to resolve network '%s' for container '%s': %v", networkMode, cName, err)
-					log.Printf("[ERROR] Skipping container '%s' due to network error", cName)
-					continue
-				}
-				networkingConfig = &network.NetworkingConfig{
-					EndpointsConfig: map[string]*network.EndpointSettings{
-						resolvedNetwork: {
-							Aliases: []string{svc.Name},
-						},
-					},
-				}
-				networkMode = ""
-				log.Printf("[SERVICE] Using named network: %s", resolvedNetwork)
-			} else {
-				log.Printf("[SERVICE] Using network_mode: %s", networkMode)
-			}
-		} else if len(svc.Networks) > 0 {
-			// Handle multiple networks - connect to ALL specified networks
-			endpointsConfig := make(map[string]*network.EndpointSettings)
-			networkNames := make([]string, 0, len(svc.Networks))
+func (c *Controller) startService(ctx context.Context, name string, project *types.Project, svc types.ServiceConfig, stackEnv map[string]string, force bool) (bool, error) {
+	if err := stackmanager.WaitForDependencies(ctx, c.cli, c.naming, project, name, svc); err != nil {
+		return false, fmt.Errorf("dependency check failed: %w", err)
+	}
+	svc.Image = serviceImageName(name, svc)
 
-			for netName := range svc.Networks {
-				resolvedNetwork, err := c.ResolveNetworkName(ctx, netName)
-				if err != nil {
-					log.Printf("[ERROR] Failed to resolve network '%s' for container '%s': %v", netName, cName, err)
-					log.Printf("[ERROR] Skipping container '%s' due to network error", cName)
-					continue
-				}
-				endpointsConfig[resolvedNetwork] = &network.EndpointSettings{
-					Aliases: []string{svc.Name},
-				}
-				networkNames = append(networkNames, resolvedNetwork)
-			}
+	exposedPorts, portBindings, err := buildPorts(svc)
+	if err != nil {
+		return false, err
+	}
+	logPortMappings(svc)
 
-			if len(endpointsConfig) == 0 {
-				log.Printf("[ERROR] No valid networks could be resolved for container '%s'", cName)
-				continue
+	mounts, binds := buildMounts(project, svc)
+	if len(mounts)+len(binds) > 0 {
+		log.Printf("[SERVICE] Mounting %d volume(s)", len(mounts)+len(binds))
+	}
+	networkMode := ""
+	var networkingConfig *network.NetworkingConfig
+
+	if svc.NetworkMode != "" {
+		networkMode = svc.NetworkMode
+		specialModes := []string{"host", "bridge", "none"}
+		isSpecialMode := slices.Contains(specialModes, networkMode)
+		if after, ok := strings.CutPrefix(networkMode, "service:"); ok {
+			networkMode = "container:" + c.naming.ServiceContainerName(project, name, after)
+			log.Printf("[SERVICE] Resolved network_mode to: %s", networkMode)
+		} else if !isSpecialMode && !strings.HasPrefix(networkMode, "container:") {
+			// Single named network in network_mode
+			resolvedNetwork, err := c.ResolveNetworkName(ctx, networkMode)
+			if err != nil {
+				return false, fmt.Errorf("failed to resolve network '%s': %w", networkMode, err)
 			}
+			endpoint := buildEndpointSettings(svc, nil)
+			endpoint.MacAddress = svc.MacAddress
 			networkingConfig = &network.NetworkingConfig{
-				EndpointsConfig: endpointsConfig,
+				EndpointsConfig: map[string]*network.EndpointSettings{resolvedNetwork: endpoint},
 			}
-			log.Printf("[SERVICE] Configured to connect to %d network(s): %v", len(networkNames), networkNames)
+			networkMode = ""
+			log.Printf("[SERVICE] Using named network: %s", resolvedNetwork)
 		} else {
-			log.Printf("[SERVICE] Using default bridge network")
+			log.Printf("[SERVICE] Using network_mode: %s", networkMode)
 		}
+	} else if len(svc.Networks) > 0 {
+		// Handle multiple networks - connect to ALL specified networks
+		endpointsConfig := make(map[string]*network.EndpointSettings)
+		networkNames := make([]string, 0, len(svc.Networks))
 
-		finalEnvMap := make(map[string]string)
-		if len(svc.EnvFiles) > 0 {
-			log.Printf("[SERVICE] env_file detected for '%s', injecting stack environment", svc.Name)
-			maps.Copy(finalEnvMap, stackEnv)
-		}
-		for k, v := range svc.Environment {
-			if v != nil {
-				finalEnvMap[k] = *v
-			} else {
-				if val, exists := stackEnv[k]; exists {
-					finalEnvMap[k] = val
-				}
+		for _, netName := range sortNetworksByPriority(svc.Networks) {
+			resolvedNetwork, err := c.ResolveNetworkName(ctx, projectNetworkName(project, netName))
+			if err != nil {
+				log.Printf("[ERROR] Failed to resolve network '%s' for service '%s': %v", netName, svc.Name, err)
+				continue
 			}
-		}
-		envList := []string{}
-		for k, v := range finalEnvMap {
-			envList = append(envList, fmt.Sprintf("%s=%s", k, v))
-		}
-		if len(envList) > 0 {
-			log.Printf("[SERVICE] Setting %d environment variable(s)", len(envList))
-		}
-		var cmdSlice []string
-		if len(svc.Command) > 0 {
-			cmdSlice = []string(svc.Command)
+			endpoint := buildEndpointSettings(svc, svc.Networks[netName])
+			// Service-level mac_address applies to the highest priority network
+			if endpoint.MacAddress == "" && len(networkNames) == 0 {
+				endpoint.MacAddress = svc.MacAddress
+			}
+			if endpoint.IPAMConfig != nil {
+				log.Printf("[SERVICE] Static address on '%s': %s %s", resolvedNetwork, endpoint.IPAMConfig.IPv4Address, endpoint.IPAMConfig.IPv6Address)
+			}
+			endpointsConfig[resolvedNetwork] = endpoint
+			networkNames = append(networkNames, resolvedNetwork)
 		}
 
-		config := &container.Config{
-			Image:        svc.Image,
-			Cmd:          cmdSlice,
-			Env:          envList,
-			ExposedPorts: exposedPorts,
-			Labels:       map[string]string{"bunshin.stack": name, "bunshin.service": svc.Name, "bunshin.managed": "true"},
-		}
-		restartPolicy := container.RestartPolicy{}
-		if svc.Restart != "" {
-			restartPolicy.Name = container.RestartPolicyMode(svc.Restart)
+		if len(endpointsConfig) == 0 {
+			return false, fmt.Errorf("no valid networks could be resolved")
 		}
-		hostConfig := &container.HostConfig{
-			Binds:         binds,
-			PortBindings:  portBindings,
-			RestartPolicy: restartPolicy,
-			CapAdd:        svc.CapAdd,
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: endpointsConfig,
 		}
+		log.Printf("[SERVICE] Configured to connect to %d network(s): %v", len(networkNames), networkNames)
+	}
 
-		if networkMode != "" {
-			hostConfig.NetworkMode = container.NetworkMode(networkMode)
-		}
-		if len(svc.CapAdd) > 0 {
-			log.Printf("[SERVICE] Adding capabilities: %v", svc.CapAdd)
+	envList := buildEnv(svc, stackEnv)
+	if len(envList) > 0 {
+		log.Printf("[SERVICE] Setting %d environment variable(s)", len(envList))
+	}
+	var cmdSlice []string
+	if len(svc.Command) > 0 {
+		cmdSlice = []string(svc.Command)
+	}
+	var entrypointSlice []string
+	if svc.Entrypoint != nil {
+		entrypointSlice = []string(svc.Entrypoint)
+	}
+
+	config := &container.Config{
+		Image:        svc.Image,
+		Cmd:          cmdSlice,
+		Entrypoint:   entrypointSlice,
+		Env:          envList,
+		ExposedPorts: exposedPorts,
+		Labels:       buildLabels(name, svc),
+		Healthcheck:  buildHealthcheck(svc.HealthCheck),
+		User:         svc.User,
+		WorkingDir:   svc.WorkingDir,
+		Hostname:     svc.Hostname,
+		Domainname:   svc.DomainName,
+		Tty:          svc.Tty,
+		OpenStdin:    svc.StdinOpen,
+		StopSignal:   svc.StopSignal,
+		StopTimeout:  buildStopTimeout(svc.StopGracePeriod),
+	}
+	if appProtocols := buildAppProtocols(svc); appProtocols != "" {
+		config.Labels["bunshin.app_protocols"] = appProtocols
+	}
+	if config.Healthcheck != nil {
+		log.Printf("[SERVICE] Configuring healthcheck: %v", config.Healthcheck.Test)
+	}
+	restartPolicy := container.RestartPolicy{}
+	if svc.Restart != "" {
+		restartPolicy.Name = container.RestartPolicyMode(svc.Restart)
+	}
+	hostConfig := &container.HostConfig{
+		Binds:          binds,
+		Mounts:         mounts,
+		Tmpfs:          buildTmpfs(svc),
+		PortBindings:   portBindings,
+		RestartPolicy:  restartPolicy,
+		CapAdd:         svc.CapAdd,
+		OomScoreAdj:    int(svc.OomScoreAdj),
+		Init:           svc.Init,
+		ReadonlyRootfs: svc.ReadOnly,
+		ShmSize:        int64(svc.ShmSize),
+		Resources:      buildResources(svc),
+		Annotations:    svc.Annotations,
+		ExtraHosts:     buildExtraHosts(svc),
+		DNS:            svc.DNS,
+		DNSSearch:      svc.DNSSearch,
+		DNSOptions:     svc.DNSOpts,
+		Links:          buildLinks(c.naming, project, name, svc),
+		Privileged:     svc.Privileged,
+		CapDrop:        svc.CapDrop,
+		SecurityOpt:    svc.SecurityOpt,
+		Sysctls:        svc.Sysctls,
+		GroupAdd:       svc.GroupAdd,
+		UsernsMode:     container.UsernsMode(svc.UserNSMode),
+		PidMode:        container.PidMode(namespaceMode(c.naming, project, name, svc.Pid)),
+		IpcMode:        container.IpcMode(namespaceMode(c.naming, project, name, svc.Ipc)),
+		UTSMode:        container.UTSMode(svc.Uts),
+		CgroupnsMode:   container.CgroupnsMode(svc.Cgroup),
+	}
+	if hostConfig.Memory > 0 || hostConfig.NanoCPUs > 0 {
+		log.Printf("[SERVICE] Applying resource limits: memory=%d bytes, cpus=%.2f", hostConfig.Memory, float64(hostConfig.NanoCPUs)/1e9)
+	}
+
+	if networkMode != "" {
+		hostConfig.NetworkMode = container.NetworkMode(networkMode)
+	}
+	if len(svc.CapAdd) > 0 {
+		log.Printf("[SERVICE] Adding capabilities: %v", svc.CapAdd)
+	}
+	if len(svc.CapDrop) > 0 {
+		log.Printf("[SERVICE] Dropping capabilities: %v", svc.CapDrop)
+	}
+	if svc.Privileged {
+		log.Printf("[SERVICE] Running '%s' in privileged mode", svc.Name)
+	}
+	if len(svc.Devices) > 0 {
+		log.Printf("[SERVICE] Mapping %d device(s)", len(svc.Devices))
+	}
+	replicas := svc.GetScale()
+	if svc.ContainerName != "" && replicas > 1 {
+		log.Printf("[WARN] Service '%s' sets container_name, ignoring %d replicas", svc.Name, replicas)
+		replicas = 1
+	}
+	imageID := ""
+	if inspect, err := c.cli.ImageInspect(ctx, svc.Image); err == nil {
+		imageID = inspect.ID
+	}
+	configHash, err := buildConfigHash(config, hostConfig, networkingConfig, svc.Platform, imageID)
+	if err != nil {
+		return false, err
+	}
+	config.Labels["bunshin.config-hash"] = configHash
+	changed := false
+	expected := make([]string, 0, replicas)
+	for number := 1; number <= replicas; number++ {
+		cName := c.naming.ContainerName(name, svc, number)
+		expected = append(expected, cName)
+		log.Printf("[SERVICE] Container name: %s", cName)
+		if inspect, err := c.cli.ContainerInspect(ctx, cName); err == nil && !force && inspect.Config.Labels["bunshin.config-hash"] == configHash {
+			if inspect.State.Paused {
+				log.Printf("[SERVICE] Unpausing existing container '%s'", cName)
+				if err := c.cli.ContainerUnpause(ctx, inspect.ID); err != nil {
+					log.Printf("[ERROR] Failed to unpause container '%s': %v", cName, err)
+				}
+			} else if !inspect.State.Running {
+				log.Printf("[SERVICE] Starting existing container '%s'", cName)
+				if err := c.cli.ContainerStart(ctx, inspect.ID, container.StartOptions{}); err != nil {
+					log.Printf("[ERROR] Failed to start container '%s': %v", cName, err)
+				}
+			} else {
+				log.Printf("[SERVICE] Container '%s' is up to date", cName)
+			}
+			continue
 		}
+		changed = true
+		replicaConfig := *config
+		replicaConfig.Labels = maps.Clone(config.Labels)
+		replicaConfig.Labels["bunshin.number"] = strconv.Itoa(number)
+
 		log.Printf("[SERVICE] Removing existing container '%s' if present", cName)
 		c.cli.ContainerRemove(ctx, cName, container.RemoveOptions{Force: true})
 
 		log.Printf("[SERVICE] Creating container '%s'", cName)
-		resp, err := c.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, cName)
+		resp, err := c.cli.ContainerCreate(ctx, &replicaConfig, hostConfig, networkingConfig, parsePlatform(svc.Platform), cName)
 		if err != nil {
 			log.Printf("[ERROR] Failed to create container '%s': %v", cName, err)
 			continue
@@ -319,18 +432,78 @@ func (c *Controller) StartStack(ctx context.Context, name string, project *types
 			log.Printf("[SERVICE] Successfully started container '%s'", cName)
 		}
 	}
-	if isUpdate {
-		log.Printf("[UPDATE] Pruning dangling images for stack '%s'", name)
-		pf := filters.NewArgs()
-		pf.Add("dangling", "true")
-		pruneReport, err := c.cli.ImagesPrune(ctx, pf)
-		if err != nil {
-			log.Printf("[UPDATE] Error pruning images: %v", err)
-		} else {
-			log.Printf("[UPDATE] Reclaimed %d bytes from dangling images", pruneReport.SpaceReclaimed)
+	c.removeExtraReplicas(ctx, name, svc.Name, expected)
+	return changed, nil
+}
+
+func (c *Controller) ScaleService(ctx context.Context, name string, project *types.Project, serviceName string, replicas int, stackEnv map[string]string) error {
+	svc, err := project.GetService(serviceName)
+	if err != nil {
+		return err
+	}
+	log.Printf("[SCALE] Scaling service '%s' in stack '%s' to %d replica(s)", serviceName, name, replicas)
+	if err := c.EnsureNetworks(ctx, name, project); err != nil {
+		return err
+	}
+	if err := c.EnsureVolumes(ctx, name, project); err != nil {
+		return err
+	}
+	svc.SetScale(replicas)
+	_, err = c.startService(ctx, name, project, svc, stackEnv, false)
+	return err
+}
+
+func (c *Controller) removeExtraReplicas(ctx context.Context, stackName, serviceName string, expected []string) {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+stackName)
+	f.Add("label", "bunshin.service="+serviceName)
+	containers, _ := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
+	for _, ctr := range containers {
+		cName := strings.TrimPrefix(ctr.Names[0], "/")
+		if slices.Contains(expected, cName) {
+			continue
+		}
+		log.Printf("[SCALE] Removing extra replica '%s'", cName)
+		if err := c.cli.ContainerRemove(ctx, ctr.ID, container.RemoveOptions{Force: true}); err != nil {
+			log.Printf("[SCALE] Error removing container '%s': %v", cName, err)
 		}
 	}
-	return nil
+}
+
+func (c *Controller) handleOrphans(ctx context.Context, stackName string, project *types.Project, remove bool, out io.Writer) ([]string, error) {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+stackName)
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	orphans := []string{}
+	for _, ctr := range containers {
+		service := ctr.Labels["bunshin.service"]
+		if _, ok := project.Services[service]; ok {
+			continue
+		}
+		if _, ok := project.DisabledServices[service]; ok {
+			continue
+		}
+		cName := strings.TrimPrefix(ctr.Names[0], "/")
+		orphans = append(orphans, cName)
+		if !remove {
+			log.Printf("[ORPHAN] Keeping orphan container '%s' of removed service '%s'", cName, service)
+			if out != nil {
+				fmt.Fprintf(out, "Keeping orphan container %s (service %s)\n", cName, service)
+			}
+			continue
+		}
+		log.Printf("[ORPHAN] Removing orphan container '%s' of removed service '%s'", cName, service)
+		if out != nil {
+			fmt.Fprintf(out, "Removing orphan container %s (service %s)\n", cName, service)
+		}
+		if err := c.cli.ContainerRemove(ctx, ctr.ID, container.RemoveOptions{Force: true}); err != nil {
+			log.Printf("[ORPHAN] Error removing container '%s': %v", cName, err)
+		}
+	}
+	return orphans, nil
 }
 
 func (c *Controller) ListContainers(name string) ([]ContainerInfo, error) {
@@ -0,0 +1,111 @@
+package dockercontroller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/image"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func (c *Controller) imageExists(ctx context.Context, imageName string) bool {
+	_, err := c.cli.ImageInspect(ctx, imageName)
+	return err == nil
+}
+
+func (c *Controller) PullImage(ctx context.Context, imageName string, platform string, out io.Writer) error {
+	if out == nil {
+		out = io.Discard
+	}
+	log.Printf("[PULL] Pulling image '%s'", imageName)
+	resp, err := c.cli.ImagePull(ctx, imageName, image.PullOptions{Platform: platform})
+	if err != nil {
+		return fmt.Errorf("failed to pull image '%s': %w", imageName, err)
+	}
+	defer resp.Close()
+	if err := streamProgress(resp, out, "[PULL]"); err != nil {
+		return fmt.Errorf("failed to pull image '%s': %w", imageName, err)
+	}
+	log.Printf("[PULL] Successfully pulled image '%s'", imageName)
+	return nil
+}
+
+func (c *Controller) prepareImage(ctx context.Context, stackName string, svc types.ServiceConfig, isUpdate bool, out io.Writer) error {
+	imageName := serviceImageName(stackName, svc)
+	policy, refresh, err := svc.GetPullPolicy()
+	if err != nil {
+		return fmt.Errorf("invalid pull_policy '%s': %w", svc.PullPolicy, err)
+	}
+	exists := c.imageExists(ctx, imageName)
+	if svc.Build != nil {
+		switch {
+		case policy == types.PullPolicyBuild || isUpdate:
+			return c.BuildService(ctx, stackName, svc, isUpdate, out)
+		case policy == types.PullPolicyAlways && svc.Image != "":
+			if err := c.PullImage(ctx, imageName, svc.Platform, out); err == nil {
+				return nil
+			}
+			log.Printf("[PULL] Falling back to building image for '%s'", svc.Name)
+			return c.BuildService(ctx, stackName, svc, false, out)
+		case exists:
+			return nil
+		case policy == types.PullPolicyNever:
+			return fmt.Errorf("image '%s' not found locally and pull_policy is never", imageName)
+		default:
+			return c.BuildService(ctx, stackName, svc, false, out)
+		}
+	}
+	switch policy {
+	case types.PullPolicyNever:
+		if !exists {
+			return fmt.Errorf("image '%s' not found locally and pull_policy is never", imageName)
+		}
+		return nil
+	case types.PullPolicyAlways:
+		return c.PullImage(ctx, imageName, svc.Platform, out)
+	case types.PullPolicyRefresh:
+		if !exists || isUpdate || c.imageOlderThan(ctx, imageName, refresh) {
+			return c.PullImage(ctx, imageName, svc.Platform, out)
+		}
+		return nil
+	default:
+		if !exists || isUpdate {
+			return c.PullImage(ctx, imageName, svc.Platform, out)
+		}
+		return nil
+	}
+}
+
+func (c *Controller) imageOlderThan(ctx context.Context, imageName string, age time.Duration) bool {
+	inspect, err := c.cli.ImageInspect(ctx, imageName)
+	if err != nil {
+		return true
+	}
+	tagged := inspect.Metadata.LastTagTime
+	if tagged.IsZero() {
+		if created, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+			tagged = created
+		}
+	}
+	return time.Since(tagged) > age
+}
+
+func parsePlatform(platform string) *ocispec.Platform {
+	if platform == "" {
+		return nil
+	}
+	parts := strings.Split(platform, "/")
+	p := &ocispec.Platform{OS: parts[0]}
+	if len(parts) > 1 {
+		p.Architecture = parts[1]
+	}
+	if len(parts) > 2 {
+		p.Variant = parts[2]
+	}
+	return p
+}
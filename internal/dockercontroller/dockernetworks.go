@@ -0,0 +1,163 @@
+package dockercontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/tanq16/bunshin/internal/stackmanager"
+)
+
+func (c *Controller) EnsureNetworks(ctx context.Context, stackName string, project *types.Project) error {
+	for key, netCfg := range project.Networks {
+		netName := projectNetworkName(project, key)
+		if netCfg.External {
+			if _, err := c.ResolveNetworkName(ctx, netName); err != nil {
+				return fmt.Errorf("external network '%s' not found: %w", netName, err)
+			}
+			log.Printf("[NETWORK] Using external network '%s'", netName)
+			continue
+		}
+		if _, err := c.cli.NetworkInspect(ctx, netName, network.InspectOptions{}); err == nil {
+			log.Printf("[NETWORK] Reusing existing network '%s'", netName)
+			continue
+		}
+		labels := map[string]string{}
+		maps.Copy(labels, netCfg.Labels)
+		labels["bunshin.stack"] = stackName
+		labels["bunshin.network"] = key
+		labels["bunshin.managed"] = "true"
+		opts := network.CreateOptions{
+			Driver:     netCfg.Driver,
+			Options:    netCfg.DriverOpts,
+			Internal:   netCfg.Internal,
+			Attachable: netCfg.Attachable,
+			EnableIPv4: netCfg.EnableIPv4,
+			EnableIPv6: netCfg.EnableIPv6,
+			Labels:     labels,
+		}
+		if netCfg.Ipam.Driver != "" || len(netCfg.Ipam.Config) > 0 {
+			opts.IPAM = &network.IPAM{Driver: netCfg.Ipam.Driver}
+			for _, pool := range netCfg.Ipam.Config {
+				opts.IPAM.Config = append(opts.IPAM.Config, network.IPAMConfig{
+					Subnet:     pool.Subnet,
+					IPRange:    pool.IPRange,
+					Gateway:    pool.Gateway,
+					AuxAddress: pool.AuxiliaryAddresses,
+				})
+			}
+		}
+		log.Printf("[NETWORK] Creating network '%s' for stack '%s'", netName, stackName)
+		if _, err := c.cli.NetworkCreate(ctx, netName, opts); err != nil {
+			return fmt.Errorf("failed to create network '%s': %w", netName, err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) RemoveNetworks(ctx context.Context, stackName string) error {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+stackName)
+	networks, err := c.cli.NetworkList(ctx, network.ListOptions{Filters: f})
+	if err != nil {
+		return fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, net := range networks {
+		inspect, err := c.cli.NetworkInspect(ctx, net.ID, network.InspectOptions{})
+		if err != nil {
+			log.Printf("[NETWORK] Error inspecting network '%s': %v", net.Name, err)
+			continue
+		}
+		if len(inspect.Containers) > 0 {
+			log.Printf("[NETWORK] Keeping network '%s', still used by %d container(s)", net.Name, len(inspect.Containers))
+			continue
+		}
+		if err := c.cli.NetworkRemove(ctx, net.ID); err != nil {
+			log.Printf("[NETWORK] Error removing network '%s': %v", net.Name, err)
+		} else {
+			log.Printf("[NETWORK] Removed network '%s'", net.Name)
+		}
+	}
+	return nil
+}
+
+func projectNetworkName(project *types.Project, key string) string {
+	if netCfg, ok := project.Networks[key]; ok && netCfg.Name != "" {
+		return netCfg.Name
+	}
+	return key
+}
+
+func buildEndpointSettings(svc types.ServiceConfig, cfg *types.ServiceNetworkConfig) *network.EndpointSettings {
+	endpoint := &network.EndpointSettings{Aliases: []string{svc.Name}}
+	if cfg == nil {
+		return endpoint
+	}
+	for _, alias := range cfg.Aliases {
+		if !slices.Contains(endpoint.Aliases, alias) {
+			endpoint.Aliases = append(endpoint.Aliases, alias)
+		}
+	}
+	if cfg.Ipv4Address != "" || cfg.Ipv6Address != "" || len(cfg.LinkLocalIPs) > 0 {
+		endpoint.IPAMConfig = &network.EndpointIPAMConfig{
+			IPv4Address:  cfg.Ipv4Address,
+			IPv6Address:  cfg.Ipv6Address,
+			LinkLocalIPs: cfg.LinkLocalIPs,
+		}
+	}
+	endpoint.MacAddress = cfg.MacAddress
+	endpoint.DriverOpts = cfg.DriverOpts
+	endpoint.GwPriority = cfg.GatewayPriority
+	return endpoint
+}
+
+func sortNetworksByPriority(networks map[string]*types.ServiceNetworkConfig) []string {
+	names := make([]string, 0, len(networks))
+	for netName := range networks {
+		names = append(names, netName)
+	}
+	priority := func(netName string) int {
+		if cfg := networks[netName]; cfg != nil {
+			return cfg.Priority
+		}
+		return 0
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if pa, pb := priority(a), priority(b); pa != pb {
+			return pb - pa
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
+
+func buildExtraHosts(svc types.ServiceConfig) []string {
+	hosts := svc.ExtraHosts.AsList(":")
+	slices.Sort(hosts)
+	return hosts
+}
+
+func buildLinks(naming stackmanager.Naming, project *types.Project, stackName string, svc types.ServiceConfig) []string {
+	links := make([]string, 0, len(svc.Links)+len(svc.ExternalLinks))
+	for _, link := range svc.Links {
+		serviceName, alias, found := strings.Cut(link, ":")
+		if !found {
+			alias = serviceName
+		}
+		links = append(links, naming.ServiceContainerName(project, stackName, serviceName)+":"+alias)
+	}
+	for _, link := range svc.ExternalLinks {
+		target, alias, found := strings.Cut(link, ":")
+		if !found {
+			alias = target
+		}
+		links = append(links, target+":"+alias)
+	}
+	return links
+}
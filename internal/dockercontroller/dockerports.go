@@ -0,0 +1,206 @@
+package dockercontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+type PortConflict struct {
+	Binding string `json:"binding"`
+	Service string `json:"service"`
+	UsedBy  string `json:"usedBy"`
+}
+
+type PortConflictError struct {
+	Stack     string
+	Conflicts []PortConflict
+}
+
+func (e *PortConflictError) Error() string {
+	lines := []string{fmt.Sprintf("host port conflicts for stack '%s':", e.Stack)}
+	for _, c := range e.Conflicts {
+		lines = append(lines, fmt.Sprintf("  - %s (service '%s') is already used by %s", c.Binding, c.Service, c.UsedBy))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type hostPortClaim struct {
+	ip       string
+	port     int
+	protocol string
+	owner    string
+}
+
+func buildPorts(svc types.ServiceConfig) (nat.PortSet, nat.PortMap, error) {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, p := range svc.Ports {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		port := nat.Port(fmt.Sprintf("%d/%s", p.Target, protocol))
+		exposedPorts[port] = struct{}{}
+		if p.Published == "" {
+			continue
+		}
+		// Docker binds both IPv4 and IPv6 when no host IP is given
+		hostIP := strings.TrimSuffix(strings.TrimPrefix(p.HostIP, "["), "]")
+		portBindings[port] = append(portBindings[port], nat.PortBinding{HostIP: hostIP, HostPort: p.Published})
+	}
+	for _, e := range svc.Expose {
+		proto, rawPort := nat.SplitProtoPort(e)
+		start, end, err := nat.ParsePortRangeToInt(rawPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid expose entry '%s': %w", e, err)
+		}
+		for i := start; i <= end; i++ {
+			exposedPorts[nat.Port(fmt.Sprintf("%d/%s", i, proto))] = struct{}{}
+		}
+	}
+	return exposedPorts, portBindings, nil
+}
+
+func logPortMappings(svc types.ServiceConfig) {
+	for _, p := range svc.Ports {
+		if p.Published == "" {
+			continue
+		}
+		if p.AppProtocol != "" {
+			log.Printf("[SERVICE] Mapping port %s:%d (%s)", p.Published, p.Target, p.AppProtocol)
+		} else {
+			log.Printf("[SERVICE] Mapping port %s:%d", p.Published, p.Target)
+		}
+	}
+}
+
+func buildAppProtocols(svc types.ServiceConfig) string {
+	protocols := []string{}
+	for _, p := range svc.Ports {
+		if p.AppProtocol == "" {
+			continue
+		}
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		protocols = append(protocols, fmt.Sprintf("%d/%s=%s", p.Target, protocol, p.AppProtocol))
+	}
+	slices.Sort(protocols)
+	return strings.Join(protocols, ",")
+}
+
+func serviceHostPorts(stackName string, svc types.ServiceConfig) []hostPortClaim {
+	if svc.NetworkMode == "host" || strings.HasPrefix(svc.NetworkMode, "service:") || strings.HasPrefix(svc.NetworkMode, "container:") {
+		return nil
+	}
+	_, portBindings, err := buildPorts(svc)
+	if err != nil {
+		return nil
+	}
+	replicas := svc.GetScale()
+	if svc.ContainerName != "" && replicas > 1 {
+		replicas = 1
+	}
+	claims := []hostPortClaim{}
+	for _, port := range slices.Sorted(maps.Keys(portBindings)) {
+		for _, binding := range portBindings[port] {
+			start, end, err := nat.ParsePortRangeToInt(binding.HostPort)
+			// A host range for a single target lets Docker pick any free port in it
+			if err != nil || end > start {
+				continue
+			}
+			for number := 1; number <= replicas; number++ {
+				owner := fmt.Sprintf("service '%s' of stack '%s'", svc.Name, stackName)
+				if replicas > 1 {
+					owner = fmt.Sprintf("replica %d of service '%s' in stack '%s'", number, svc.Name, stackName)
+				}
+				claims = append(claims, hostPortClaim{ip: binding.HostIP, port: start, protocol: port.Proto(), owner: owner})
+			}
+		}
+	}
+	return claims
+}
+
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
+func (a hostPortClaim) overlaps(b hostPortClaim) bool {
+	if a.port != b.port || a.protocol != b.protocol {
+		return false
+	}
+	return isWildcardIP(a.ip) || isWildcardIP(b.ip) || a.ip == b.ip
+}
+
+func (a hostPortClaim) String() string {
+	ip := a.ip
+	if ip == "" {
+		ip = "*"
+	} else if strings.Contains(ip, ":") {
+		ip = "[" + ip + "]"
+	}
+	return fmt.Sprintf("%s:%d/%s", ip, a.port, a.protocol)
+}
+
+func (c *Controller) CheckPortConflicts(ctx context.Context, name string, project *types.Project, others map[string]*types.Project) error {
+	taken := []hostPortClaim{}
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, ctr := range containers {
+		if ctr.Labels["bunshin.stack"] == name {
+			continue
+		}
+		owner := fmt.Sprintf("container '%s'", strings.TrimPrefix(ctr.Names[0], "/"))
+		if stack := ctr.Labels["bunshin.stack"]; stack != "" {
+			owner = fmt.Sprintf("container '%s' of stack '%s'", strings.TrimPrefix(ctr.Names[0], "/"), stack)
+		}
+		for _, p := range ctr.Ports {
+			if p.PublicPort == 0 {
+				continue
+			}
+			taken = append(taken, hostPortClaim{ip: p.IP, port: int(p.PublicPort), protocol: p.Type, owner: owner})
+		}
+	}
+	for stackName, other := range others {
+		if stackName == name {
+			continue
+		}
+		for _, svc := range other.Services {
+			taken = append(taken, serviceHostPorts(stackName, svc)...)
+		}
+	}
+
+	conflicts := []PortConflict{}
+	for _, svcName := range slices.Sorted(maps.Keys(project.Services)) {
+		svc := project.Services[svcName]
+		for _, claim := range serviceHostPorts(name, svc) {
+			for _, t := range taken {
+				if claim.overlaps(t) {
+					conflicts = append(conflicts, PortConflict{Binding: claim.String(), Service: svc.Name, UsedBy: t.owner})
+					break
+				}
+			}
+			taken = append(taken, claim)
+		}
+	}
+	if len(conflicts) > 0 {
+		slices.SortFunc(conflicts, func(a, b PortConflict) int { return strings.Compare(a.Service+a.Binding, b.Service+b.Binding) })
+		for _, conflict := range conflicts {
+			log.Printf("[PORTS] Conflict on %s for service '%s': used by %s", conflict.Binding, conflict.Service, conflict.UsedBy)
+		}
+		return &PortConflictError{Stack: name, Conflicts: conflicts}
+	}
+	log.Printf("[PORTS] No host port conflicts for stack '%s'", name)
+	return nil
+}
@@ -0,0 +1,41 @@
+package dockercontroller
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestServiceHostPorts(t *testing.T) {
+	replicas := 2
+	svc := types.ServiceConfig{
+		Name:   "web",
+		Deploy: &types.DeployConfig{Replicas: &replicas},
+		Ports: []types.ServicePortConfig{
+			{Target: 80, Published: "8080"},
+			{Target: 53, Published: "5353", Protocol: "udp", HostIP: "[::1]"},
+			{Target: 443, Published: "9000-9010"},
+			{Target: 9090},
+		},
+	}
+	got := []string{}
+	for _, claim := range serviceHostPorts("app", svc) {
+		got = append(got, claim.String()+" "+claim.owner)
+	}
+	slices.Sort(got)
+	want := []string{
+		"*:8080/tcp replica 1 of service 'web' in stack 'app'",
+		"*:8080/tcp replica 2 of service 'web' in stack 'app'",
+		"[::1]:5353/udp replica 1 of service 'web' in stack 'app'",
+		"[::1]:5353/udp replica 2 of service 'web' in stack 'app'",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("claims = %v, want %v", got, want)
+	}
+
+	svc.NetworkMode = "host"
+	if claims := serviceHostPorts("app", svc); len(claims) != 0 {
+		t.Errorf("host network claims = %v, want none", claims)
+	}
+}
@@ -0,0 +1,203 @@
+package dockercontroller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func (c *Controller) ServiceAction(ctx context.Context, name string, project *types.Project, serviceName string, action string, signal string, stackEnv map[string]string, out io.Writer) error {
+	svc, err := project.GetService(serviceName)
+	if err != nil {
+		return err
+	}
+	log.Printf("[SERVICE] Stack '%s' - executing action '%s' on service '%s'", name, action, serviceName)
+	switch action {
+	case "start", "recreate":
+		if err := c.EnsureNetworks(ctx, name, project); err != nil {
+			return err
+		}
+		if err := c.EnsureVolumes(ctx, name, project); err != nil {
+			return err
+		}
+		if err := c.ensureDependencies(ctx, name, project, svc, stackEnv, out, map[string]bool{}); err != nil {
+			return err
+		}
+		if err := c.prepareImage(ctx, name, svc, false, out); err != nil {
+			return err
+		}
+		changed, err := c.startService(ctx, name, project, svc, stackEnv, action == "recreate")
+		if err != nil {
+			return err
+		}
+		if changed {
+			return c.recreateDependents(ctx, name, project, svc, stackEnv)
+		}
+		return nil
+	case "stop", "pause", "unpause", "kill":
+		for _, ctr := range c.serviceContainers(ctx, name, serviceName) {
+			if err := c.containerAction(ctx, ctr, action, signal); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "restart":
+		if len(c.serviceContainers(ctx, name, serviceName)) == 0 {
+			return fmt.Errorf("service '%s' has no containers to restart", serviceName)
+		}
+		if err := c.restartService(ctx, name, serviceName); err != nil {
+			return err
+		}
+		return c.restartDependents(ctx, name, project, svc)
+	case "pull":
+		return c.prepareImage(ctx, name, svc, true, out)
+	}
+	return fmt.Errorf("unknown service action '%s'", action)
+}
+
+func (c *Controller) containerAction(ctx context.Context, ctr container.Summary, action string, signal string) error {
+	cName := strings.TrimPrefix(ctr.Names[0], "/")
+	var err error
+	switch action {
+	case "stop":
+		if ctr.State != container.StateRunning && ctr.State != container.StatePaused {
+			return nil
+		}
+		log.Printf("[SERVICE] Stopping container '%s'", cName)
+		err = c.cli.ContainerStop(ctx, ctr.ID, container.StopOptions{})
+	case "restart":
+		log.Printf("[SERVICE] Restarting container '%s'", cName)
+		err = c.cli.ContainerRestart(ctx, ctr.ID, container.StopOptions{})
+	case "pause":
+		if ctr.State != container.StateRunning {
+			return nil
+		}
+		log.Printf("[SERVICE] Pausing container '%s'", cName)
+		err = c.cli.ContainerPause(ctx, ctr.ID)
+	case "unpause":
+		if ctr.State != container.StatePaused {
+			return nil
+		}
+		log.Printf("[SERVICE] Unpausing container '%s'", cName)
+		err = c.cli.ContainerUnpause(ctx, ctr.ID)
+	case "kill":
+		if ctr.State != container.StateRunning && ctr.State != container.StatePaused {
+			return nil
+		}
+		log.Printf("[SERVICE] Killing container '%s' (signal: %s)", cName, signal)
+		err = c.cli.ContainerKill(ctx, ctr.ID, signal)
+	default:
+		return fmt.Errorf("unknown container action '%s'", action)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s container '%s': %w", action, cName, err)
+	}
+	return nil
+}
+
+func (c *Controller) serviceContainers(ctx context.Context, stackName string, serviceName string) []container.Summary {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+stackName)
+	f.Add("label", "bunshin.service="+serviceName)
+	containers, _ := c.cli.ContainerList(ctx, container.ListOptions{Filters: f, All: true})
+	return containers
+}
+
+func (c *Controller) ensureDependencies(ctx context.Context, name string, project *types.Project, svc types.ServiceConfig, stackEnv map[string]string, out io.Writer, seen map[string]bool) error {
+	for _, depName := range slices.Sorted(maps.Keys(svc.DependsOn)) {
+		if seen[depName] {
+			continue
+		}
+		seen[depName] = true
+		dep, err := project.GetService(depName)
+		if err != nil {
+			if svc.DependsOn[depName].Required {
+				return fmt.Errorf("dependency '%s' of service '%s' is not available: %w", depName, svc.Name, err)
+			}
+			continue
+		}
+		if err := c.ensureDependencies(ctx, name, project, dep, stackEnv, out, seen); err != nil {
+			return err
+		}
+		containers := c.serviceContainers(ctx, name, depName)
+		if len(containers) == 0 {
+			log.Printf("[SERVICE] Bringing up missing dependency '%s'", depName)
+			if err := c.prepareImage(ctx, name, dep, false, out); err != nil {
+				return err
+			}
+			if _, err := c.startService(ctx, name, project, dep, stackEnv, false); err != nil {
+				return fmt.Errorf("failed to start dependency '%s': %w", depName, err)
+			}
+			continue
+		}
+		for _, ctr := range containers {
+			switch ctr.State {
+			case container.StateRunning:
+				continue
+			case container.StatePaused:
+				if err := c.containerAction(ctx, ctr, "unpause", ""); err != nil {
+					return fmt.Errorf("failed to unpause dependency '%s': %w", depName, err)
+				}
+				continue
+			}
+			log.Printf("[SERVICE] Starting stopped dependency container '%s'", strings.TrimPrefix(ctr.Names[0], "/"))
+			if err := c.cli.ContainerStart(ctx, ctr.ID, container.StartOptions{}); err != nil {
+				return fmt.Errorf("failed to start dependency '%s': %w", depName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func restartingDependents(project *types.Project, svc types.ServiceConfig) []string {
+	dependents := project.GetDependentsForService(svc, func(d types.ServiceDependency) bool { return d.Restart })
+	slices.Sort(dependents)
+	return dependents
+}
+
+func (c *Controller) recreateDependents(ctx context.Context, name string, project *types.Project, svc types.ServiceConfig, stackEnv map[string]string) error {
+	for _, depName := range restartingDependents(project, svc) {
+		if len(c.serviceContainers(ctx, name, depName)) == 0 {
+			continue
+		}
+		dependent := project.Services[depName]
+		log.Printf("[SERVICE] Recreating dependent service '%s' of '%s'", depName, svc.Name)
+		if _, err := c.startService(ctx, name, project, dependent, stackEnv, true); err != nil {
+			return fmt.Errorf("failed to recreate dependent '%s': %w", depName, err)
+		}
+		if err := c.recreateDependents(ctx, name, project, dependent, stackEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) restartService(ctx context.Context, name string, serviceName string) error {
+	for _, ctr := range c.serviceContainers(ctx, name, serviceName) {
+		if err := c.containerAction(ctx, ctr, "restart", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) restartDependents(ctx context.Context, name string, project *types.Project, svc types.ServiceConfig) error {
+	for _, depName := range restartingDependents(project, svc) {
+		log.Printf("[SERVICE] Restarting dependent service '%s' of '%s'", depName, svc.Name)
+		if err := c.restartService(ctx, name, depName); err != nil {
+			return err
+		}
+		if err := c.restartDependents(ctx, name, project, project.Services[depName]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
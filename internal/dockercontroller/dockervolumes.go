@@ -0,0 +1,155 @@
+package dockercontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func (c *Controller) EnsureVolumes(ctx context.Context, stackName string, project *types.Project) error {
+	for key, vol := range project.Volumes {
+		volName := vol.Name
+		if volName == "" {
+			volName = key
+		}
+		if vol.External {
+			if _, err := c.cli.VolumeInspect(ctx, volName); err != nil {
+				return fmt.Errorf("external volume '%s' not found: %w", volName, err)
+			}
+			log.Printf("[VOLUME] Using external volume '%s'", volName)
+			continue
+		}
+		if _, err := c.cli.VolumeInspect(ctx, volName); err == nil {
+			log.Printf("[VOLUME] Reusing existing volume '%s'", volName)
+			continue
+		}
+		labels := map[string]string{}
+		maps.Copy(labels, vol.Labels)
+		labels["bunshin.stack"] = stackName
+		labels["bunshin.volume"] = key
+		labels["bunshin.managed"] = "true"
+		log.Printf("[VOLUME] Creating volume '%s' for stack '%s'", volName, stackName)
+		if _, err := c.cli.VolumeCreate(ctx, volume.CreateOptions{
+			Name:       volName,
+			Driver:     vol.Driver,
+			DriverOpts: vol.DriverOpts,
+			Labels:     labels,
+		}); err != nil {
+			return fmt.Errorf("failed to create volume '%s': %w", volName, err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) RemoveVolumes(ctx context.Context, stackName string) error {
+	f := filters.NewArgs()
+	f.Add("label", "bunshin.stack="+stackName)
+	resp, err := c.cli.VolumeList(ctx, volume.ListOptions{Filters: f})
+	if err != nil {
+		return fmt.Errorf("failed to list volumes: %w", err)
+	}
+	log.Printf("[VOLUME] Found %d volume(s) to remove for stack '%s'", len(resp.Volumes), stackName)
+	for _, vol := range resp.Volumes {
+		if err := c.cli.VolumeRemove(ctx, vol.Name, false); err != nil {
+			log.Printf("[VOLUME] Error removing volume '%s': %v", vol.Name, err)
+		} else {
+			log.Printf("[VOLUME] Removed volume '%s'", vol.Name)
+		}
+	}
+	return nil
+}
+
+func resolveVolumeName(project *types.Project, source string) string {
+	if vol, ok := project.Volumes[source]; ok && vol.Name != "" {
+		return vol.Name
+	}
+	return source
+}
+
+func buildMounts(project *types.Project, svc types.ServiceConfig) ([]mount.Mount, []string) {
+	mounts := []mount.Mount{}
+	binds := []string{}
+	for _, v := range svc.Volumes {
+		switch v.Type {
+		case types.VolumeTypeBind, "":
+			// SELinux relabeling is only available through the legacy binds syntax
+			if v.Bind != nil && v.Bind.SELinux != "" {
+				binds = append(binds, buildBindString(v))
+				continue
+			}
+			m := mount.Mount{Type: mount.TypeBind, Source: v.Source, Target: v.Target, ReadOnly: v.ReadOnly, Consistency: mount.Consistency(v.Consistency)}
+			if v.Bind != nil {
+				m.BindOptions = &mount.BindOptions{
+					Propagation:      mount.Propagation(v.Bind.Propagation),
+					CreateMountpoint: bool(v.Bind.CreateHostPath),
+				}
+			}
+			mounts = append(mounts, m)
+		case types.VolumeTypeVolume:
+			m := mount.Mount{Type: mount.TypeVolume, Target: v.Target, ReadOnly: v.ReadOnly, Consistency: mount.Consistency(v.Consistency)}
+			if v.Source != "" {
+				m.Source = resolveVolumeName(project, v.Source)
+			}
+			if v.Volume != nil {
+				m.VolumeOptions = &mount.VolumeOptions{
+					NoCopy:  v.Volume.NoCopy,
+					Subpath: v.Volume.Subpath,
+					Labels:  v.Volume.Labels,
+				}
+			}
+			mounts = append(mounts, m)
+		case types.VolumeTypeTmpfs:
+			m := mount.Mount{Type: mount.TypeTmpfs, Target: v.Target, ReadOnly: v.ReadOnly}
+			if v.Tmpfs != nil {
+				m.TmpfsOptions = &mount.TmpfsOptions{
+					SizeBytes: int64(v.Tmpfs.Size),
+					Mode:      os.FileMode(v.Tmpfs.Mode),
+				}
+			}
+			mounts = append(mounts, m)
+		case types.VolumeTypeImage:
+			m := mount.Mount{Type: mount.TypeImage, Source: v.Source, Target: v.Target, ReadOnly: v.ReadOnly}
+			if v.Image != nil {
+				m.ImageOptions = &mount.ImageOptions{Subpath: v.Image.SubPath}
+			}
+			mounts = append(mounts, m)
+		default:
+			log.Printf("[SERVICE] Unsupported volume type '%s' for target '%s'", v.Type, v.Target)
+		}
+	}
+	return mounts, binds
+}
+
+func buildBindString(v types.ServiceVolumeConfig) string {
+	opts := []string{"rw"}
+	if v.ReadOnly {
+		opts[0] = "ro"
+	}
+	if v.Bind.SELinux != "" {
+		opts = append(opts, v.Bind.SELinux)
+	}
+	if v.Bind.Propagation != "" {
+		opts = append(opts, v.Bind.Propagation)
+	}
+	return fmt.Sprintf("%s:%s:%s", v.Source, v.Target, strings.Join(opts, ","))
+}
+
+func buildTmpfs(svc types.ServiceConfig) map[string]string {
+	if len(svc.Tmpfs) == 0 {
+		return nil
+	}
+	tmpfs := make(map[string]string, len(svc.Tmpfs))
+	for _, entry := range svc.Tmpfs {
+		target, opts, _ := strings.Cut(entry, ":")
+		tmpfs[target] = opts
+	}
+	return tmpfs
+}
@@ -3,6 +3,7 @@ package envmanager
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"os"
@@ -29,6 +30,9 @@ func (m *Manager) Encrypt(data []byte) ([]byte, error) {
 	block, _ := aes.NewCipher(m.envKey)
 	gcm, _ := cipher.NewGCM(block)
 	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
 	return gcm.Seal(nonce, nonce, data, nil), nil
 }
 
@@ -83,6 +87,73 @@ func (m *Manager) WriteEnv(name string, envContent string) error {
 	return os.WriteFile(filepath.Join(m.dataPath, "env", name+".env"), enc, 0644)
 }
 
+func (m *Manager) envFilePath(name string, file string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(file))
+	if clean == ".env" {
+		return filepath.Join(m.dataPath, "env", name+".env"), nil
+	}
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid env file path '%s'", file)
+	}
+	return filepath.Join(m.dataPath, "env", name, clean), nil
+}
+
+func (m *Manager) ReadEnvFile(name string, file string) (string, error) {
+	path, err := m.envFilePath(name, file)
+	if err != nil {
+		return "", err
+	}
+	enc, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	dec, err := m.Decrypt(enc)
+	if err != nil {
+		return "", err
+	}
+	return string(dec), nil
+}
+
+func (m *Manager) WriteEnvFile(name string, file string, envContent string) error {
+	path, err := m.envFilePath(name, file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	enc, err := m.Encrypt([]byte(envContent))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, enc, 0644)
+}
+
+func (m *Manager) DeleteEnvFile(name string, file string) error {
+	path, err := m.envFilePath(name, file)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (m *Manager) ListEnvFiles(name string) []string {
+	files := []string{}
+	if _, err := os.Stat(filepath.Join(m.dataPath, "env", name+".env")); err == nil {
+		files = append(files, ".env")
+	}
+	root := filepath.Join(m.dataPath, "env", name)
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files
+}
+
 func (m *Manager) GetEnvMap(name string) map[string]string {
 	envStr, err := m.ReadEnv(name)
 	if err != nil {
@@ -0,0 +1,45 @@
+package envmanager
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestEncryptUsesFreshNonce(t *testing.T) {
+	m := New(t.TempDir(), "password")
+	a, err := m.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("encrypting the same data twice produced identical ciphertext")
+	}
+	for _, data := range [][]byte{a, b} {
+		plain, err := m.Decrypt(data)
+		if err != nil || string(plain) != "secret" {
+			t.Errorf("round trip: got %q, %v", plain, err)
+		}
+	}
+}
+
+func TestDecryptZeroNonceData(t *testing.T) {
+	m := New(t.TempDir(), "password")
+	// Files written before nonces were randomised carry an all-zero nonce prefix
+	block, _ := aes.NewCipher(m.envKey)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := gcm.Seal(nonce, nonce, []byte("KEY=value"), nil)
+	plain, err := m.Decrypt(legacy)
+	if err != nil {
+		t.Fatalf("decrypting zero-nonce data: %v", err)
+	}
+	if string(plain) != "KEY=value" {
+		t.Errorf("got %q, want %q", plain, "KEY=value")
+	}
+}
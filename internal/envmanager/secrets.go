@@ -0,0 +1,65 @@
+package envmanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (m *Manager) secretPath(name string, secret string) (string, error) {
+	if secret == "" || secret != filepath.Base(secret) || strings.HasPrefix(secret, ".") {
+		return "", fmt.Errorf("invalid secret name '%s'", secret)
+	}
+	return filepath.Join(m.dataPath, "secrets", name, secret), nil
+}
+
+func (m *Manager) ReadSecret(name string, secret string) (string, error) {
+	path, err := m.secretPath(name, secret)
+	if err != nil {
+		return "", err
+	}
+	enc, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	dec, err := m.Decrypt(enc)
+	if err != nil {
+		return "", err
+	}
+	return string(dec), nil
+}
+
+func (m *Manager) WriteSecret(name string, secret string, content string) error {
+	path, err := m.secretPath(name, secret)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	enc, err := m.Encrypt([]byte(content))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, enc, 0600)
+}
+
+func (m *Manager) DeleteSecret(name string, secret string) error {
+	path, err := m.secretPath(name, secret)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (m *Manager) ListSecrets(name string) []string {
+	secrets := []string{}
+	entries, _ := os.ReadDir(filepath.Join(m.dataPath, "secrets", name))
+	for _, e := range entries {
+		if !e.IsDir() {
+			secrets = append(secrets, e.Name())
+		}
+	}
+	return secrets
+}
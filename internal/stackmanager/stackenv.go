@@ -0,0 +1,70 @@
+package stackmanager
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func (m *Manager) resolveServiceEnvironment(name string, project *types.Project) error {
+	for svcName, svc := range project.Services {
+		resolved, err := m.resolveEnvironment(name, project, svc)
+		if err != nil {
+			return err
+		}
+		project.Services[svcName] = resolved
+	}
+	return nil
+}
+
+func (m *Manager) resolveEnvironment(name string, project *types.Project, svc types.ServiceConfig) (types.ServiceConfig, error) {
+	svcName := svc.Name
+	svc.Environment = svc.Environment.Resolve(project.Environment.Resolve)
+	environment := svc.Environment.ToMapping()
+	for _, envFile := range svc.EnvFiles {
+		docName, err := envDocumentName(project.WorkingDir, envFile.Path)
+		if err != nil {
+			return svc, fmt.Errorf("service '%s': %w", svcName, err)
+		}
+		content, err := m.envMgr.ReadEnvFile(name, docName)
+		if err != nil {
+			if os.IsNotExist(err) && !bool(envFile.Required) {
+				log.Printf("[ENV] Optional env_file '%s' for service '%s' not found, skipping", docName, svcName)
+				continue
+			}
+			return svc, fmt.Errorf("service '%s': env file '%s' not available: %w", svcName, docName, err)
+		}
+		lookup := func(k string) (string, bool) {
+			if v, ok := project.Environment.Resolve(k); ok {
+				return v, true
+			}
+			if v, ok := svc.Environment[k]; ok && v != nil {
+				return *v, true
+			}
+			return "", false
+		}
+		if err := dotenv.ParseWithFormat(strings.NewReader(content), docName, environment, lookup, envFile.Format); err != nil {
+			return svc, fmt.Errorf("service '%s': failed to parse env file '%s': %w", svcName, docName, err)
+		}
+		log.Printf("[ENV] Loaded env_file '%s' for service '%s'", docName, svcName)
+	}
+	svc.Environment = environment.ToMappingWithEquals().OverrideBy(svc.Environment)
+	return svc, nil
+}
+
+func envDocumentName(workingDir string, path string) (string, error) {
+	rel := path
+	if filepath.IsAbs(path) {
+		var err error
+		rel, err = filepath.Rel(workingDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("env file '%s' must live inside the stack", path)
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(rel)), nil
+}
@@ -0,0 +1,82 @@
+package stackmanager
+
+import (
+	"context"
+	"maps"
+	"os"
+	"testing"
+)
+
+type docEnv struct {
+	stubEnv
+	stack map[string]string
+	docs  map[string]string
+}
+
+func (e docEnv) GetEnvMap(name string) map[string]string { return e.stack }
+func (e docEnv) ReadEnvFile(name string, file string) (string, error) {
+	content, ok := e.docs[file]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return content, nil
+}
+
+func TestServiceEnvironmentFromEnvFiles(t *testing.T) {
+	env := docEnv{
+		stack: map[string]string{"STACK_SECRET": "leak"},
+		docs: map[string]string{
+			"base.env":     "SHARED=base\nBASE_ONLY=1\nFROM_ENV=base\n",
+			"override.env": "SHARED=override\n",
+			"other.env":    "OTHER=1\n",
+		},
+	}
+	m := New(t.TempDir(), env, NewNaming(false))
+	yml := `services:
+  app:
+    image: nginx
+    env_file:
+      - base.env
+      - override.env
+      - path: optional.env
+        required: false
+    environment:
+      FROM_ENV: inline
+  other:
+    image: nginx
+    env_file: other.env
+`
+	if err := m.SaveStack("web", yml, ""); err != nil {
+		t.Fatal(err)
+	}
+	project, err := m.LoadProject(context.Background(), "web", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]map[string]string{
+		"app":   {"SHARED": "override", "BASE_ONLY": "1", "FROM_ENV": "inline"},
+		"other": {"OTHER": "1"},
+	}
+	for svcName, want := range tests {
+		got := map[string]string{}
+		for k, v := range project.Services[svcName].Environment {
+			if v != nil {
+				got[k] = *v
+			}
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("service '%s' environment = %v, want %v", svcName, got, want)
+		}
+	}
+}
+
+func TestServiceEnvironmentRequiredEnvFileMissing(t *testing.T) {
+	m := New(t.TempDir(), docEnv{stack: map[string]string{}, docs: map[string]string{}}, NewNaming(false))
+	yml := "services:\n  app:\n    image: nginx\n    env_file: missing.env\n"
+	if err := m.SaveStack("web", yml, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.LoadProject(context.Background(), "web", nil); err == nil {
+		t.Error("expected an error for a missing required env file")
+	}
+}
@@ -0,0 +1,191 @@
+package stackmanager
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+type StackFile struct {
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	Referenced bool   `json:"referenced"`
+}
+
+func ValidateStackName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid stack name '%s'", name)
+	}
+	return nil
+}
+
+func (m *Manager) stackFilePath(name string, file string) (string, error) {
+	if err := ValidateStackName(name); err != nil {
+		return "", err
+	}
+	clean := filepath.Clean(filepath.FromSlash(file))
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("invalid file path '%s'", file)
+	}
+	if first, _, _ := strings.Cut(filepath.ToSlash(clean), "/"); first == "overrides" {
+		return "", fmt.Errorf("file '%s' is managed as an override", file)
+	}
+	stackDir := m.StackDir(name)
+	path := filepath.Join(stackDir, clean)
+	// Reject symlinks pointing outside the stack directory
+	root, err := filepath.EvalSymlinks(stackDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		return "", err
+	}
+	existing := path
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if rel, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(rel) {
+				return "", fmt.Errorf("file '%s' resolves outside the stack directory", file)
+			}
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		existing = filepath.Dir(existing)
+	}
+}
+
+func (m *Manager) ListFiles(ctx context.Context, name string) ([]StackFile, error) {
+	if err := ValidateStackName(name); err != nil {
+		return nil, err
+	}
+	stackDir := m.StackDir(name)
+	referenced := map[string]bool{}
+	if project, err := m.LoadProject(ctx, name, []string{"*"}); err == nil {
+		referenced = referencedPaths(project)
+	} else {
+		log.Printf("[FILES] Could not load stack '%s' to detect referenced files: %v", name, err)
+	}
+	files := []StackFile{}
+	err := filepath.WalkDir(stackDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == stackDir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(stackDir, path)
+		if d.IsDir() {
+			if rel == "overrides" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, StackFile{Path: filepath.ToSlash(rel), Size: info.Size(), Referenced: isReferenced(referenced, path)})
+		return nil
+	})
+	return files, err
+}
+
+func (m *Manager) ReadFile(name string, file string) (string, error) {
+	path, err := m.stackFilePath(name, file)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("file '%s' is not a text file", file)
+	}
+	return string(data), nil
+}
+
+func (m *Manager) WriteFile(name string, file string, content string) error {
+	path, err := m.stackFilePath(name, file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func (m *Manager) DeleteFile(name string, file string) error {
+	path, err := m.stackFilePath(name, file)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (m *Manager) RenameFile(name string, from string, to string) error {
+	src, err := m.stackFilePath(name, from)
+	if err != nil {
+		return err
+	}
+	dst, err := m.stackFilePath(name, to)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("file '%s' already exists", to)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+func referencedPaths(project *types.Project) map[string]bool {
+	paths := map[string]bool{}
+	for _, svc := range project.Services {
+		for _, v := range svc.Volumes {
+			if v.Type == types.VolumeTypeBind && filepath.IsAbs(v.Source) {
+				paths[filepath.Clean(v.Source)] = true
+			}
+		}
+		if svc.Build != nil && filepath.IsAbs(svc.Build.Context) {
+			paths[filepath.Clean(svc.Build.Context)] = true
+		}
+	}
+	for _, c := range project.Configs {
+		if c.File != "" {
+			paths[filepath.Clean(c.File)] = true
+		}
+	}
+	for _, s := range project.Secrets {
+		if s.File != "" {
+			paths[filepath.Clean(s.File)] = true
+		}
+	}
+	return paths
+}
+
+func isReferenced(referenced map[string]bool, path string) bool {
+	for p := path; ; p = filepath.Dir(p) {
+		if referenced[p] {
+			return true
+		}
+		if p == filepath.Dir(p) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package stackmanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStackFilesRejectTraversal(t *testing.T) {
+	dataPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataPath, "secret.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := New(filepath.Join(dataPath, "data"), nil, NewNaming(false))
+
+	for _, name := range []string{"", "..", "../..", "a/b", ".hidden"} {
+		if _, err := m.ListFiles(context.Background(), name); err == nil {
+			t.Errorf("list: expected error for stack name %q", name)
+		}
+		if _, err := m.ReadFile(name, "compose.yml"); err == nil {
+			t.Errorf("read: expected error for stack name %q", name)
+		}
+		if err := m.WriteFile(name, "compose.yml", "x"); err == nil {
+			t.Errorf("write: expected error for stack name %q", name)
+		}
+		if err := m.DeleteFile(name, "compose.yml"); err == nil {
+			t.Errorf("delete: expected error for stack name %q", name)
+		}
+	}
+
+	for _, file := range []string{"../../../secret.txt", "/etc/passwd", "overrides/x.yml"} {
+		if _, err := m.ReadFile("web", file); err == nil {
+			t.Errorf("read: expected error for file %q", file)
+		}
+		if err := m.WriteFile("web", file, "x"); err == nil {
+			t.Errorf("write: expected error for file %q", file)
+		}
+		if err := m.DeleteFile("web", file); err == nil {
+			t.Errorf("delete: expected error for file %q", file)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataPath, "secret.txt")); err != nil {
+		t.Errorf("file outside the data root was touched: %v", err)
+	}
+}
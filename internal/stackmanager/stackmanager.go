@@ -14,24 +14,43 @@ import (
 	"github.com/docker/docker/client"
 )
 
+type Naming struct {
+	separator string
+}
+
+func NewNaming(composeNames bool) Naming {
+	if composeNames {
+		return Naming{separator: "-"}
+	}
+	return Naming{separator: "_"}
+}
+
 type Manager struct {
 	dataPath string
 	envMgr   EnvManager
+	naming   Naming
 }
 
 type EnvManager interface {
 	GetEnvMap(name string) map[string]string
 	ReadEnv(name string) (string, error)
+	ReadEnvFile(name string, file string) (string, error)
+	ReadSecret(name string, secret string) (string, error)
 	WriteEnv(name string, envContent string) error
 }
 
-func New(dataPath string, envMgr EnvManager) *Manager {
+func New(dataPath string, envMgr EnvManager, naming Naming) *Manager {
 	return &Manager{
 		dataPath: dataPath,
 		envMgr:   envMgr,
+		naming:   naming,
 	}
 }
 
+func (m *Manager) Naming() Naming {
+	return m.naming
+}
+
 func (m *Manager) ListStacks() []string {
 	files, _ := os.ReadDir(filepath.Join(m.dataPath, "stacks"))
 	stacks := []string{}
@@ -53,43 +72,103 @@ func (m *Manager) GetStack(name string) (string, string, error) {
 	return string(yml), envStr, nil
 }
 
+func (m *Manager) StackDir(name string) string {
+	return filepath.Join(m.dataPath, "stacks", name)
+}
+
 func (m *Manager) SaveStack(name string, yaml string, env string) error {
+	if err := os.MkdirAll(m.StackDir(name), 0755); err != nil {
+		return err
+	}
 	if err := os.WriteFile(filepath.Join(m.dataPath, "stacks", name+".yml"), []byte(yaml), 0644); err != nil {
 		return err
 	}
 	return m.envMgr.WriteEnv(name, env)
 }
 
-func (m *Manager) LoadProject(ctx context.Context, name string) (*types.Project, error) {
+func (m *Manager) LoadProject(ctx context.Context, name string, profiles []string) (*types.Project, error) {
 	ymlData, err := os.ReadFile(filepath.Join(m.dataPath, "stacks", name+".yml"))
 	if err != nil {
 		return nil, err
 	}
-	envMap := m.envMgr.GetEnvMap(name)
-	tempDir, err := os.MkdirTemp("", "bunshin-"+name+"-")
+	settings, err := m.GetSettings(name)
 	if err != nil {
 		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
-	envContent, _ := m.envMgr.ReadEnv(name)
-	if envContent != "" {
-		os.WriteFile(filepath.Join(tempDir, ".env"), []byte(envContent), 0644)
+	if profiles == nil {
+		profiles = settings.Profiles
+	}
+	overrideFiles, err := m.overrideConfigFiles(name, settings.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	stackDir := m.StackDir(name)
+	if err := os.MkdirAll(stackDir, 0755); err != nil {
+		return nil, err
+	}
+	configFiles := append([]types.ConfigFile{{Filename: name + ".yml", Content: ymlData}}, overrideFiles...)
+	for i, f := range configFiles {
+		if configFiles[i].Content, err = m.pinIncludeDirs(f.Content); err != nil {
+			return nil, fmt.Errorf("failed to parse '%s': %w", f.Filename, err)
+		}
 	}
 	project, err := loader.LoadWithContext(ctx, types.ConfigDetails{
-		WorkingDir: tempDir,
-		ConfigFiles: []types.ConfigFile{
-			{Filename: name + ".yml", Content: ymlData},
-		},
-		Environment: envMap,
+		WorkingDir:  stackDir,
+		ConfigFiles: configFiles,
+		Environment: m.envMgr.GetEnvMap(name),
 	}, func(opts *loader.Options) {
 		opts.SetProjectName(name, true)
+		opts.Profiles = profiles
+		opts.SkipResolveEnvironment = true
+		opts.ResourceLoaders = append(opts.ResourceLoaders, stackResourceLoader{m})
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err := m.resolveServiceEnvironment(name, project); err != nil {
+		return nil, err
+	}
+	if len(project.DisabledServices) > 0 {
+		log.Printf("[LOAD] Stack '%s' active profiles %v, skipping %d service(s)", name, profiles, len(project.DisabledServices))
+	}
 	return project, nil
 }
 
+func (m *Manager) EnableServices(name string, project *types.Project, services ...string) (*types.Project, error) {
+	enabled, err := project.WithServicesEnabled(services...)
+	if err != nil {
+		return nil, err
+	}
+	for svcName, svc := range enabled.Services {
+		if _, active := project.Services[svcName]; active {
+			continue
+		}
+		resolved, err := m.resolveEnvironment(name, enabled, svc)
+		if err != nil {
+			return nil, err
+		}
+		enabled.Services[svcName] = resolved
+		log.Printf("[LOAD] Enabled service '%s' of stack '%s'", svcName, name)
+	}
+	return enabled, nil
+}
+
+func (m *Manager) LoadOtherProjects(ctx context.Context, name string) map[string]*types.Project {
+	projects := map[string]*types.Project{}
+	for _, stack := range m.ListStacks() {
+		if stack == name {
+			continue
+		}
+		project, err := m.LoadProject(ctx, stack, nil)
+		if err != nil {
+			log.Printf("[LOAD] Skipping stack '%s': %v", stack, err)
+			continue
+		}
+		projects[stack] = project
+	}
+	return projects
+}
+
 func FindService(project *types.Project, serviceName string) *types.ServiceConfig {
 	for i := range project.Services {
 		if project.Services[i].Name == serviceName {
@@ -100,6 +179,20 @@ func FindService(project *types.Project, serviceName string) *types.ServiceConfi
 	return nil
 }
 
+func (n Naming) ContainerName(stackName string, svc types.ServiceConfig, number int) string {
+	if svc.ContainerName != "" {
+		return svc.ContainerName
+	}
+	return fmt.Sprintf("%s%s%s%s%d", stackName, n.separator, svc.Name, n.separator, number)
+}
+
+func (n Naming) ServiceContainerName(project *types.Project, stackName string, serviceName string) string {
+	if svc := FindService(project, serviceName); svc != nil {
+		return n.ContainerName(stackName, *svc, 1)
+	}
+	return n.ContainerName(stackName, types.ServiceConfig{Name: serviceName}, 1)
+}
+
 func SortServicesByDependencies(services types.Services) []types.ServiceConfig {
 	visited := make(map[string]bool)
 	recStack := make(map[string]bool)
@@ -139,7 +232,7 @@ func SortServicesByDependencies(services types.Services) []types.ServiceConfig {
 	return result
 }
 
-func WaitForDependencies(ctx context.Context, cli *client.Client, project *types.Project, stackName string, svc types.ServiceConfig) error {
+func WaitForDependencies(ctx context.Context, cli *client.Client, naming Naming, project *types.Project, stackName string, svc types.ServiceConfig) error {
 	if len(svc.DependsOn) == 0 {
 		return nil
 	}
@@ -150,10 +243,7 @@ func WaitForDependencies(ctx context.Context, cli *client.Client, project *types
 		if depService == nil {
 			return fmt.Errorf("dependency '%s' not found in project", depName)
 		}
-		targetContainerName := depService.ContainerName
-		if targetContainerName == "" {
-			targetContainerName = fmt.Sprintf("%s_%s_1", stackName, depName)
-		}
+		targetContainerName := naming.ContainerName(stackName, *depService, 1)
 		timeout := time.After(60 * time.Second)
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
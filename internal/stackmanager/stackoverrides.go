@@ -0,0 +1,125 @@
+package stackmanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+type OverrideFile struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (m *Manager) overridePath(name string, file string) (string, error) {
+	if err := ValidateStackName(name); err != nil {
+		return "", err
+	}
+	if file == "" || file != filepath.Base(file) || strings.HasPrefix(file, ".") {
+		return "", fmt.Errorf("invalid override file name '%s'", file)
+	}
+	if !strings.HasSuffix(file, ".yml") && !strings.HasSuffix(file, ".yaml") {
+		return "", fmt.Errorf("override file '%s' must have a .yml or .yaml extension", file)
+	}
+	return filepath.Join(m.StackDir(name), "overrides", file), nil
+}
+
+func (m *Manager) ListOverrides(name string) ([]OverrideFile, error) {
+	settings, err := m.GetSettings(name)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Overrides, nil
+}
+
+func (m *Manager) GetOverride(name string, file string) (string, error) {
+	path, err := m.overridePath(name, file)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (m *Manager) SaveOverride(name string, file string, content string) error {
+	path, err := m.overridePath(name, file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	settings, err := m.GetSettings(name)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(settings.Overrides, func(o OverrideFile) bool { return o.Name == file }) {
+		settings.Overrides = append(settings.Overrides, OverrideFile{Name: file, Enabled: true})
+		return m.SaveSettings(name, settings)
+	}
+	return nil
+}
+
+func (m *Manager) DeleteOverride(name string, file string) error {
+	path, err := m.overridePath(name, file)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	settings, err := m.GetSettings(name)
+	if err != nil {
+		return err
+	}
+	settings.Overrides = slices.DeleteFunc(settings.Overrides, func(o OverrideFile) bool { return o.Name == file })
+	return m.SaveSettings(name, settings)
+}
+
+func (m *Manager) SetOverrides(name string, overrides []OverrideFile) error {
+	seen := map[string]bool{}
+	for _, o := range overrides {
+		path, err := m.overridePath(name, o.Name)
+		if err != nil {
+			return err
+		}
+		if seen[o.Name] {
+			return fmt.Errorf("override file '%s' listed twice", o.Name)
+		}
+		seen[o.Name] = true
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("override file '%s' does not exist", o.Name)
+		}
+	}
+	settings, err := m.GetSettings(name)
+	if err != nil {
+		return err
+	}
+	settings.Overrides = overrides
+	return m.SaveSettings(name, settings)
+}
+
+func (m *Manager) overrideConfigFiles(name string, overrides []OverrideFile) ([]types.ConfigFile, error) {
+	files := []types.ConfigFile{}
+	for _, o := range overrides {
+		if !o.Enabled {
+			continue
+		}
+		content, err := m.GetOverride(name, o.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read override '%s': %w", o.Name, err)
+		}
+		files = append(files, types.ConfigFile{Filename: o.Name, Content: []byte(content)})
+	}
+	return files, nil
+}
@@ -0,0 +1,126 @@
+package stackmanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	stackRefPrefix    = "stack:"
+	fragmentRefPrefix = "fragment:"
+)
+
+type stackResourceLoader struct {
+	m *Manager
+}
+
+func (m *Manager) FragmentsDir() string {
+	return filepath.Join(m.dataPath, "fragments")
+}
+
+func (l stackResourceLoader) Accept(path string) bool {
+	return strings.HasPrefix(path, stackRefPrefix) || strings.HasPrefix(path, fragmentRefPrefix)
+}
+
+func (l stackResourceLoader) Load(_ context.Context, path string) (string, error) {
+	local, err := l.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(local); err != nil {
+		return "", fmt.Errorf("cannot load '%s': %w", path, err)
+	}
+	return local, nil
+}
+
+func (l stackResourceLoader) Dir(path string) string {
+	if stack, ok := strings.CutPrefix(path, stackRefPrefix); ok {
+		return l.m.StackDir(stack)
+	}
+	local, err := l.resolve(path)
+	if err != nil {
+		return filepath.Dir(path)
+	}
+	return filepath.Dir(local)
+}
+
+func (l stackResourceLoader) resolve(path string) (string, error) {
+	if stack, ok := strings.CutPrefix(path, stackRefPrefix); ok {
+		if ValidateStackName(stack) != nil {
+			return "", fmt.Errorf("invalid stack reference '%s'", path)
+		}
+		return filepath.Join(l.m.dataPath, "stacks", stack+".yml"), nil
+	}
+	if fragment, ok := strings.CutPrefix(path, fragmentRefPrefix); ok {
+		if !filepath.IsLocal(fragment) {
+			return "", fmt.Errorf("invalid fragment reference '%s'", path)
+		}
+		return filepath.Join(l.m.FragmentsDir(), fragment), nil
+	}
+	return path, nil
+}
+
+// compose-go uses the directory of the loaded file as the project directory of an
+// include, so stack references get their working directory pinned explicitly
+func (m *Manager) pinIncludeDirs(content []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return content, nil
+	}
+	root := doc.Content[0]
+	changed := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "include" || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, entry := range root.Content[i+1].Content {
+			switch entry.Kind {
+			case yaml.ScalarNode:
+				if stack, ok := strings.CutPrefix(entry.Value, stackRefPrefix); ok {
+					root.Content[i+1].Content[j] = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+						{Kind: yaml.ScalarNode, Value: "path"}, entry,
+						{Kind: yaml.ScalarNode, Value: "project_directory"}, {Kind: yaml.ScalarNode, Value: m.StackDir(stack)},
+					}}
+					changed = true
+				}
+			case yaml.MappingNode:
+				if stack, ok := includedStack(entry); ok {
+					entry.Content = append(entry.Content,
+						&yaml.Node{Kind: yaml.ScalarNode, Value: "project_directory"}, &yaml.Node{Kind: yaml.ScalarNode, Value: m.StackDir(stack)})
+					changed = true
+				}
+			}
+		}
+	}
+	if !changed {
+		return content, nil
+	}
+	return yaml.Marshal(&doc)
+}
+
+func includedStack(entry *yaml.Node) (string, bool) {
+	var path *yaml.Node
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		switch entry.Content[i].Value {
+		case "project_directory":
+			return "", false
+		case "path":
+			path = entry.Content[i+1]
+		}
+	}
+	if path != nil && path.Kind == yaml.SequenceNode && len(path.Content) > 0 {
+		path = path.Content[0]
+	}
+	if path == nil || path.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return strings.CutPrefix(path.Value, stackRefPrefix)
+}
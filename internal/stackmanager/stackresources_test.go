@@ -0,0 +1,40 @@
+package stackmanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubEnv struct{}
+
+func (stubEnv) GetEnvMap(name string) map[string]string { return map[string]string{} }
+func (stubEnv) ReadEnv(name string) (string, error)     { return "", nil }
+func (stubEnv) ReadEnvFile(name string, file string) (string, error) {
+	return "", os.ErrNotExist
+}
+func (stubEnv) ReadSecret(name string, secret string) (string, error) { return "", os.ErrNotExist }
+func (stubEnv) WriteEnv(name string, envContent string) error         { return nil }
+
+func TestIncludeStackResolvesRelativeBinds(t *testing.T) {
+	m := New(t.TempDir(), stubEnv{}, NewNaming(false))
+	if err := m.SaveStack("db", "services:\n  db:\n    image: postgres\n    volumes:\n      - ./data:/var/lib/postgresql/data\n", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, include := range []string{"- stack:db", "- path: stack:db"} {
+		if err := m.SaveStack("app", "include:\n  "+include+"\nservices:\n  app:\n    image: nginx\n    volumes:\n      - ./html:/usr/share/nginx/html\n", ""); err != nil {
+			t.Fatal(err)
+		}
+		project, err := m.LoadProject(context.Background(), "app", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", include, err)
+		}
+		if got, want := project.Services["db"].Volumes[0].Source, filepath.Join(m.StackDir("db"), "data"); got != want {
+			t.Errorf("%s: included bind source = %q, want %q", include, got, want)
+		}
+		if got, want := project.Services["app"].Volumes[0].Source, filepath.Join(m.StackDir("app"), "html"); got != want {
+			t.Errorf("%s: bind source = %q, want %q", include, got, want)
+		}
+	}
+}
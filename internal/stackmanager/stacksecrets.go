@@ -0,0 +1,152 @@
+package stackmanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func (m *Manager) RuntimeDir(name string) string {
+	return filepath.Join(m.dataPath, "run", name)
+}
+
+func (m *Manager) MaterializeSecrets(name string, project *types.Project) error {
+	for svcName, svc := range project.Services {
+		svcDir := filepath.Join(m.RuntimeDir(name), svcName)
+		for _, ref := range svc.Secrets {
+			secret, ok := project.Secrets[ref.Source]
+			if !ok {
+				return fmt.Errorf("service '%s' refers to undefined secret '%s'", svcName, ref.Source)
+			}
+			content, err := m.fileObjectContent(name, project, types.FileObjectConfig(secret))
+			if err != nil {
+				return fmt.Errorf("service '%s': secret '%s': %w", svcName, ref.Source, err)
+			}
+			target := ref.Target
+			if target == "" {
+				target = ref.Source
+			}
+			if !path.IsAbs(target) {
+				target = path.Join("/run/secrets", target)
+			}
+			source, err := writeRuntimeFile(filepath.Join(svcDir, "secrets"), target, content, types.FileReferenceConfig(ref))
+			if err != nil {
+				return fmt.Errorf("service '%s': secret '%s': %w", svcName, ref.Source, err)
+			}
+			svc.Volumes = append(svc.Volumes, types.ServiceVolumeConfig{Type: types.VolumeTypeBind, Source: source, Target: target, ReadOnly: true})
+			log.Printf("[SECRET] Mounting secret '%s' at '%s' for service '%s'", ref.Source, target, svcName)
+		}
+		for _, ref := range svc.Configs {
+			config, ok := project.Configs[ref.Source]
+			if !ok {
+				return fmt.Errorf("service '%s' refers to undefined config '%s'", svcName, ref.Source)
+			}
+			content, err := m.fileObjectContent(name, project, types.FileObjectConfig(config))
+			if err != nil {
+				return fmt.Errorf("service '%s': config '%s': %w", svcName, ref.Source, err)
+			}
+			target := ref.Target
+			if target == "" {
+				target = ref.Source
+			}
+			target = path.Join("/", target)
+			source, err := writeRuntimeFile(filepath.Join(svcDir, "configs"), target, content, types.FileReferenceConfig(ref))
+			if err != nil {
+				return fmt.Errorf("service '%s': config '%s': %w", svcName, ref.Source, err)
+			}
+			svc.Volumes = append(svc.Volumes, types.ServiceVolumeConfig{Type: types.VolumeTypeBind, Source: source, Target: target, ReadOnly: true})
+			log.Printf("[CONFIG] Mounting config '%s' at '%s' for service '%s'", ref.Source, target, svcName)
+		}
+		svc.Secrets = nil
+		svc.Configs = nil
+		project.Services[svcName] = svc
+	}
+	return nil
+}
+
+func (m *Manager) RemoveSecrets(name string) error {
+	return os.RemoveAll(m.RuntimeDir(name))
+}
+
+func (m *Manager) fileObjectContent(name string, project *types.Project, obj types.FileObjectConfig) (string, error) {
+	switch {
+	case obj.Content != "":
+		return obj.Content, nil
+	case obj.Environment != "":
+		value, ok := project.Environment[obj.Environment]
+		if !ok {
+			return "", fmt.Errorf("environment variable '%s' is not set", obj.Environment)
+		}
+		return value, nil
+	case obj.File != "":
+		data, err := os.ReadFile(obj.File)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case bool(obj.External):
+		return m.envMgr.ReadSecret(name, obj.Name)
+	}
+	return "", fmt.Errorf("no content source declared")
+}
+
+func writeRuntimeFile(dir string, target string, content string, ref types.FileReferenceConfig) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	mode := os.FileMode(0444)
+	if ref.Mode != nil {
+		mode = os.FileMode(*ref.Mode)
+	}
+	// The digest in the file name changes the bind source, so containers are recreated when the content changes
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%o:%s", ref.UID, ref.GID, mode, content)))
+	suffix := "-" + strings.ReplaceAll(strings.TrimPrefix(target, "/"), "/", "_")
+	file := filepath.Join(dir, hex.EncodeToString(digest[:6])+suffix)
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	if ref.UID != "" || ref.GID != "" {
+		uid, gid := 0, 0
+		var err error
+		if ref.UID != "" {
+			if uid, err = strconv.Atoi(ref.UID); err != nil {
+				return "", fmt.Errorf("invalid uid '%s'", ref.UID)
+			}
+		}
+		if ref.GID != "" {
+			if gid, err = strconv.Atoi(ref.GID); err != nil {
+				return "", fmt.Errorf("invalid gid '%s'", ref.GID)
+			}
+		}
+		if err := os.Chown(file, uid, gid); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Chmod(file, mode); err != nil {
+		return "", err
+	}
+	// Drop files left behind by earlier content of the same target
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.Name() != filepath.Base(file) && len(e.Name()) == len(filepath.Base(file)) && strings.HasSuffix(e.Name(), suffix) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return "", err
+			}
+		}
+	}
+	return file, nil
+}
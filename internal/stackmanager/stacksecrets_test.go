@@ -0,0 +1,36 @@
+package stackmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestWriteRuntimeFileRemovesStaleContent(t *testing.T) {
+	dir := t.TempDir()
+	other, err := writeRuntimeFile(dir, "/run/secrets/other", "keep", types.FileReferenceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := writeRuntimeFile(dir, "/run/secrets/db", "old", types.FileReferenceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := writeRuntimeFile(dir, "/run/secrets/db", "new", types.FileReferenceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("expected a new file for changed content")
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("stale file %s was not removed", filepath.Base(first))
+	}
+	for _, path := range []string{second, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package stackmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type StackSettings struct {
+	Profiles  []string       `json:"profiles"`
+	Overrides []OverrideFile `json:"overrides"`
+}
+
+func (m *Manager) settingsPath(name string) string {
+	return filepath.Join(m.dataPath, "stacks", name+".json")
+}
+
+func (m *Manager) GetSettings(name string) (StackSettings, error) {
+	settings := StackSettings{Profiles: []string{}, Overrides: []OverrideFile{}}
+	if err := ValidateStackName(name); err != nil {
+		return settings, err
+	}
+	data, err := os.ReadFile(m.settingsPath(name))
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func (m *Manager) SaveSettings(name string, settings StackSettings) error {
+	if err := ValidateStackName(name); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.settingsPath(name), data, 0644)
+}
+
+func ValidateProfiles(profiles []string) error {
+	for _, p := range profiles {
+		if p == "" {
+			return fmt.Errorf("empty profile name")
+		}
+	}
+	return nil
+}
+
+func (m *Manager) SetProfiles(name string, profiles []string) error {
+	if err := ValidateProfiles(profiles); err != nil {
+		return err
+	}
+	settings, err := m.GetSettings(name)
+	if err != nil {
+		return err
+	}
+	if profiles == nil {
+		profiles = []string{}
+	}
+	settings.Profiles = profiles
+	return m.SaveSettings(name, settings)
+}
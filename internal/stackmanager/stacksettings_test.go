@@ -0,0 +1,36 @@
+package stackmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettingsAndOverridesRejectTraversal(t *testing.T) {
+	dataPath := t.TempDir()
+	m := New(filepath.Join(dataPath, "data"), nil, NewNaming(false))
+	for _, name := range []string{"", "..", "../..", "a/b", ".hidden"} {
+		if _, err := m.GetSettings(name); err == nil {
+			t.Errorf("get settings: expected error for stack name %q", name)
+		}
+		if err := m.SaveSettings(name, StackSettings{}); err == nil {
+			t.Errorf("save settings: expected error for stack name %q", name)
+		}
+		if err := m.SaveOverride(name, "debug.yml", "services: {}\n"); err == nil {
+			t.Errorf("save override: expected error for stack name %q", name)
+		}
+		if _, err := m.GetOverride(name, "debug.yml"); err == nil {
+			t.Errorf("get override: expected error for stack name %q", name)
+		}
+		if err := m.DeleteOverride(name, "debug.yml"); err == nil {
+			t.Errorf("delete override: expected error for stack name %q", name)
+		}
+	}
+	entries, err := os.ReadDir(dataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("files were written outside the data directory: %v", entries)
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/docker/client"
 	"github.com/tanq16/bunshin/internal/dockercontroller"
 	"github.com/tanq16/bunshin/internal/envmanager"
@@ -22,10 +28,17 @@ var staticFiles embed.FS
 
 func main() {
 	dataPath := "./data"
+	composeNames := false
 	for i, arg := range os.Args {
 		if arg == "--data" && i+1 < len(os.Args) {
 			dataPath = os.Args[i+1]
 		}
+		if arg == "--compose-names" {
+			composeNames = true
+		}
+	}
+	if absPath, err := filepath.Abs(dataPath); err == nil {
+		dataPath = absPath
 	}
 
 	pw := os.Getenv("BUNSHIN_ENV_PW")
@@ -35,14 +48,15 @@ func main() {
 
 	os.MkdirAll(filepath.Join(dataPath, "stacks"), 0755)
 	os.MkdirAll(filepath.Join(dataPath, "env"), 0755)
+	os.MkdirAll(filepath.Join(dataPath, "fragments"), 0755)
 
 	envMgr := envmanager.New(dataPath, pw)
-	stackMgr := stackmanager.New(dataPath, envMgr)
+	stackMgr := stackmanager.New(dataPath, envMgr, stackmanager.NewNaming(composeNames))
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		log.Fatal("Moby SDK Connection Error:", err)
 	}
-	dockerCtrl := dockercontroller.New(cli)
+	dockerCtrl := dockercontroller.New(cli, stackMgr.Naming())
 
 	staticFS, err := fs.Sub(staticFiles, "frontend")
 	if err != nil {
@@ -52,8 +66,19 @@ func main() {
 	http.HandleFunc("/api/stacks", handleListStacks(stackMgr))
 	http.HandleFunc("/api/stack/get", handleGetStack(stackMgr))
 	http.HandleFunc("/api/stack/save", handleSaveStack(stackMgr))
+	http.HandleFunc("/api/stack/settings", handleSettings(stackMgr))
+	http.HandleFunc("/api/stack/overrides", handleOverrides(stackMgr))
+	http.HandleFunc("/api/stack/override", handleOverride(stackMgr))
+	http.HandleFunc("/api/stack/files", handleListFiles(stackMgr))
+	http.HandleFunc("/api/stack/file", handleStackFile(stackMgr))
+	http.HandleFunc("/api/stack/file/rename", handleRenameFile(stackMgr))
+	http.HandleFunc("/api/stack/envfiles", handleListEnvFiles(envMgr))
+	http.HandleFunc("/api/stack/envfile", handleEnvFile(envMgr))
+	http.HandleFunc("/api/stack/secrets", handleListSecrets(envMgr))
+	http.HandleFunc("/api/stack/secret", handleSecret(envMgr))
 	http.HandleFunc("/api/stack/status", handleStatus(dockerCtrl))
 	http.HandleFunc("/api/stack/action", handleAction(dockerCtrl, stackMgr, envMgr))
+	http.HandleFunc("/api/stack/service/action", handleServiceAction(dockerCtrl, stackMgr, envMgr))
 	http.HandleFunc("/api/stack/containers", handleContainers(dockerCtrl))
 	http.HandleFunc("/ws/logs", dockerCtrl.HandleLogs)
 	http.HandleFunc("/ws/shell", dockerCtrl.HandleShell)
@@ -103,6 +128,290 @@ func handleSaveStack(stackMgr *stackmanager.Manager) http.HandlerFunc {
 	}
 }
 
+func handleSettings(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting settings request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var req struct {
+				Profiles []string `json:"profiles"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[API] Error decoding settings for stack '%s': %v", name, err)
+				w.WriteHeader(400)
+				return
+			}
+			if err := stackmanager.ValidateProfiles(req.Profiles); err != nil {
+				log.Printf("[API] Invalid settings for stack '%s': %v", name, err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Saving settings for stack '%s'", name)
+			if err := stackMgr.SetProfiles(name, req.Profiles); err != nil {
+				log.Printf("[API] Error saving settings for stack '%s': %v", name, err)
+				w.WriteHeader(500)
+				return
+			}
+		}
+		settings, err := stackMgr.GetSettings(name)
+		if err != nil {
+			log.Printf("[API] Error reading settings for stack '%s': %v", name, err)
+			w.WriteHeader(500)
+			return
+		}
+		json.NewEncoder(w).Encode(settings)
+	}
+}
+
+func handleOverrides(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting overrides request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var overrides []stackmanager.OverrideFile
+			if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil {
+				log.Printf("[API] Error decoding overrides for stack '%s': %v", name, err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Updating %d override(s) for stack '%s'", len(overrides), name)
+			if err := stackMgr.SetOverrides(name, overrides); err != nil {
+				log.Printf("[API] Error updating overrides for stack '%s': %v", name, err)
+				w.WriteHeader(400)
+				return
+			}
+		}
+		overrides, err := stackMgr.ListOverrides(name)
+		if err != nil {
+			log.Printf("[API] Error listing overrides for stack '%s': %v", name, err)
+			w.WriteHeader(500)
+			return
+		}
+		json.NewEncoder(w).Encode(overrides)
+	}
+}
+
+func handleOverride(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting override request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		file := r.URL.Query().Get("file")
+		switch r.Method {
+		case http.MethodPost:
+			var req struct{ Content string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[API] Error decoding override request: %v", err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Saving override '%s' for stack '%s'", file, name)
+			if err := stackMgr.SaveOverride(name, file, req.Content); err != nil {
+				log.Printf("[API] Error saving override '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		case http.MethodDelete:
+			log.Printf("[API] Deleting override '%s' for stack '%s'", file, name)
+			if err := stackMgr.DeleteOverride(name, file); err != nil {
+				log.Printf("[API] Error deleting override '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		default:
+			content, err := stackMgr.GetOverride(name, file)
+			if err != nil {
+				log.Printf("[API] Error reading override '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(404)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"file": file, "content": content})
+		}
+	}
+}
+
+func handleListFiles(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting file listing: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		files, err := stackMgr.ListFiles(r.Context(), name)
+		if err != nil {
+			log.Printf("[API] Error listing files for stack '%s': %v", name, err)
+			w.WriteHeader(500)
+			return
+		}
+		log.Printf("[API] Found %d file(s) for stack '%s'", len(files), name)
+		json.NewEncoder(w).Encode(files)
+	}
+}
+
+func handleStackFile(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		file := r.URL.Query().Get("file")
+		switch r.Method {
+		case http.MethodPost:
+			var req struct{ Content string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[API] Error decoding file request: %v", err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Saving file '%s' for stack '%s'", file, name)
+			if err := stackMgr.WriteFile(name, file, req.Content); err != nil {
+				log.Printf("[API] Error saving file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		case http.MethodDelete:
+			log.Printf("[API] Deleting file '%s' for stack '%s'", file, name)
+			if err := stackMgr.DeleteFile(name, file); err != nil {
+				log.Printf("[API] Error deleting file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		default:
+			content, err := stackMgr.ReadFile(name, file)
+			if err != nil {
+				log.Printf("[API] Error reading file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(404)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"file": file, "content": content})
+		}
+	}
+}
+
+func handleRenameFile(stackMgr *stackmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		var req struct{ From, To string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[API] Error decoding rename request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		log.Printf("[API] Renaming file '%s' to '%s' for stack '%s'", req.From, req.To, name)
+		if err := stackMgr.RenameFile(name, req.From, req.To); err != nil {
+			log.Printf("[API] Error renaming file '%s' for stack '%s': %v", req.From, name, err)
+			w.WriteHeader(400)
+		}
+	}
+}
+
+func handleListEnvFiles(envMgr *envmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting env file listing: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		files := envMgr.ListEnvFiles(name)
+		log.Printf("[API] Found %d env file(s) for stack '%s'", len(files), name)
+		json.NewEncoder(w).Encode(files)
+	}
+}
+
+func handleEnvFile(envMgr *envmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting env file request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		file := r.URL.Query().Get("file")
+		switch r.Method {
+		case http.MethodPost:
+			var req struct{ Content string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[API] Error decoding env file request: %v", err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Saving env file '%s' for stack '%s'", file, name)
+			if err := envMgr.WriteEnvFile(name, file, req.Content); err != nil {
+				log.Printf("[API] Error saving env file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		case http.MethodDelete:
+			log.Printf("[API] Deleting env file '%s' for stack '%s'", file, name)
+			if err := envMgr.DeleteEnvFile(name, file); err != nil {
+				log.Printf("[API] Error deleting env file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(500)
+			}
+		default:
+			content, err := envMgr.ReadEnvFile(name, file)
+			if err != nil {
+				log.Printf("[API] Error reading env file '%s' for stack '%s': %v", file, name, err)
+				w.WriteHeader(404)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"file": file, "content": content})
+		}
+	}
+}
+
+func handleListSecrets(envMgr *envmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting secret listing: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		secrets := envMgr.ListSecrets(name)
+		log.Printf("[API] Found %d secret(s) for stack '%s'", len(secrets), name)
+		json.NewEncoder(w).Encode(secrets)
+	}
+}
+
+func handleSecret(envMgr *envmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if err := stackmanager.ValidateStackName(name); err != nil {
+			log.Printf("[API] Rejecting secret request: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		secret := r.URL.Query().Get("secret")
+		switch r.Method {
+		case http.MethodPost:
+			var req struct{ Content string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				log.Printf("[API] Error decoding secret request: %v", err)
+				w.WriteHeader(400)
+				return
+			}
+			log.Printf("[API] Saving secret '%s' for stack '%s'", secret, name)
+			if err := envMgr.WriteSecret(name, secret, req.Content); err != nil {
+				log.Printf("[API] Error saving secret '%s' for stack '%s': %v", secret, name, err)
+				w.WriteHeader(500)
+			}
+		case http.MethodDelete:
+			log.Printf("[API] Deleting secret '%s' for stack '%s'", secret, name)
+			if err := envMgr.DeleteSecret(name, secret); err != nil {
+				log.Printf("[API] Error deleting secret '%s' for stack '%s': %v", secret, name, err)
+				w.WriteHeader(500)
+			}
+		default:
+			w.WriteHeader(405)
+		}
+	}
+}
+
 func handleStatus(dockerCtrl *dockercontroller.Controller) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Query().Get("name")
@@ -117,14 +426,62 @@ func handleAction(dockerCtrl *dockercontroller.Controller, stackMgr *stackmanage
 		action := r.URL.Query().Get("action")
 		ctx := context.Background()
 		log.Printf("[ACTION] Stack '%s' - executing action: %s", name, action)
-		if action == "stop" {
-			if err := dockerCtrl.StopStack(ctx, name); err != nil {
-				log.Printf("[STOP] Error stopping stack '%s': %v", name, err)
+		profiles, err := parseProfiles(r)
+		if err != nil {
+			log.Printf("[ACTION] Invalid profiles for stack '%s': %v", name, err)
+			w.WriteHeader(400)
+			return
+		}
+		if action == "scale" {
+			service := r.URL.Query().Get("service")
+			replicas, err := strconv.Atoi(r.URL.Query().Get("replicas"))
+			if service == "" || err != nil || replicas < 0 {
+				log.Printf("[SCALE] Invalid scale request for stack '%s': service='%s' replicas='%s'", name, service, r.URL.Query().Get("replicas"))
+				w.WriteHeader(400)
+				return
+			}
+			project, err := loadStack(ctx, stackMgr, name, profiles)
+			if err != nil {
+				log.Printf("[SCALE] Error loading stack '%s': %v", name, err)
 				w.WriteHeader(500)
 				return
 			}
-		} else {
-			project, err := stackMgr.LoadProject(ctx, name)
+			if svc, err := project.GetService(service); err == nil {
+				svc.SetScale(replicas)
+				project.Services[service] = svc
+			}
+			if err := dockerCtrl.CheckPortConflicts(ctx, name, project, stackMgr.LoadOtherProjects(ctx, name)); err != nil {
+				log.Printf("[SCALE] %v", err)
+				writeActionError(w, nil, err)
+				return
+			}
+			if err := dockerCtrl.ScaleService(ctx, name, project, service, replicas, envMgr.GetEnvMap(name)); err != nil {
+				log.Printf("[SCALE] Error scaling service '%s' in stack '%s': %v", service, name, err)
+				w.WriteHeader(500)
+				return
+			}
+		} else if action == "down" {
+			removeVolumes := r.URL.Query().Get("volumes") == "true"
+			if err := dockerCtrl.DownStack(ctx, name, removeVolumes); err != nil {
+				log.Printf("[DOWN] Error taking down stack '%s': %v", name, err)
+				w.WriteHeader(500)
+				return
+			}
+			if err := stackMgr.RemoveSecrets(name); err != nil {
+				log.Printf("[DOWN] Error removing secret files for stack '%s': %v", name, err)
+			}
+		} else if slices.Contains([]string{"stop", "restart", "pause", "unpause", "kill"}, action) {
+			project, err := stackMgr.LoadProject(ctx, name, profiles)
+			if err != nil {
+				log.Printf("[ACTION] Stack '%s' could not be loaded, ignoring dependency order: %v", name, err)
+			}
+			if err := dockerCtrl.StackAction(ctx, name, project, action, r.URL.Query().Get("signal")); err != nil {
+				log.Printf("[ACTION] Error running '%s' on stack '%s': %v", action, name, err)
+				w.WriteHeader(500)
+				return
+			}
+		} else if action == "start" || action == "update" {
+			project, err := loadStack(ctx, stackMgr, name, profiles)
 			if err != nil {
 				log.Printf("[START] Error loading stack '%s': %v", name, err)
 				w.WriteHeader(500)
@@ -132,17 +489,141 @@ func handleAction(dockerCtrl *dockercontroller.Controller, stackMgr *stackmanage
 			}
 			stackEnv := envMgr.GetEnvMap(name)
 			isUpdate := action == "update"
-			if err := dockerCtrl.StartStack(ctx, name, project, isUpdate, stackEnv); err != nil {
+			var out io.Writer
+			if r.URL.Query().Get("stream") == "true" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				out = flushWriter{w}
+			}
+			if err := dockerCtrl.CheckPortConflicts(ctx, name, project, stackMgr.LoadOtherProjects(ctx, name)); err != nil {
+				log.Printf("[START] %v", err)
+				writeActionError(w, out, err)
+				return
+			}
+			removeOrphans := r.URL.Query().Get("keep_orphans") != "true"
+			orphans, err := dockerCtrl.StartStack(ctx, name, project, isUpdate, removeOrphans, stackEnv, out)
+			if err != nil {
 				log.Printf("[START] Error starting stack '%s': %v", name, err)
-				w.WriteHeader(500)
+				writeActionError(w, out, err)
 				return
 			}
 			log.Printf("[ACTION] Stack '%s' action '%s' completed successfully", name, action)
+			if out != nil {
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"orphans": orphans, "orphansRemoved": removeOrphans && len(orphans) > 0})
+			return
+		} else {
+			log.Printf("[ACTION] Unknown action '%s' for stack '%s'", action, name)
+			w.WriteHeader(400)
+			return
 		}
 		w.WriteHeader(200)
 	}
 }
 
+func handleServiceAction(dockerCtrl *dockercontroller.Controller, stackMgr *stackmanager.Manager, envMgr *envmanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		service := r.URL.Query().Get("service")
+		action := r.URL.Query().Get("action")
+		ctx := context.Background()
+		log.Printf("[ACTION] Stack '%s' - executing action '%s' on service '%s'", name, action, service)
+		if service == "" {
+			w.WriteHeader(400)
+			return
+		}
+		profiles, err := parseProfiles(r)
+		if err != nil {
+			log.Printf("[ACTION] Invalid profiles for stack '%s': %v", name, err)
+			w.WriteHeader(400)
+			return
+		}
+		project, err := loadStack(ctx, stackMgr, name, profiles, service)
+		if err != nil {
+			log.Printf("[ACTION] Error loading stack '%s': %v", name, err)
+			w.WriteHeader(500)
+			return
+		}
+		var out io.Writer
+		if r.URL.Query().Get("stream") == "true" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			out = flushWriter{w}
+		}
+		if action == "start" || action == "recreate" {
+			if err := dockerCtrl.CheckPortConflicts(ctx, name, project, stackMgr.LoadOtherProjects(ctx, name)); err != nil {
+				log.Printf("[ACTION] %v", err)
+				writeActionError(w, out, err)
+				return
+			}
+		}
+		if err := dockerCtrl.ServiceAction(ctx, name, project, service, action, r.URL.Query().Get("signal"), envMgr.GetEnvMap(name), out); err != nil {
+			log.Printf("[ACTION] Error running '%s' on service '%s' of stack '%s': %v", action, service, name, err)
+			writeActionError(w, out, err)
+			return
+		}
+		log.Printf("[ACTION] Service '%s' action '%s' completed successfully", service, action)
+		if out == nil {
+			w.WriteHeader(200)
+		}
+	}
+}
+
+func writeActionError(w http.ResponseWriter, out io.Writer, err error) {
+	if out != nil {
+		fmt.Fprintf(out, "ERROR: %v\n", err)
+		return
+	}
+	var conflictErr *dockercontroller.PortConflictError
+	if errors.As(err, &conflictErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(409)
+		json.NewEncoder(w).Encode(map[string]any{"error": err.Error(), "conflicts": conflictErr.Conflicts})
+		return
+	}
+	w.WriteHeader(500)
+}
+
+func loadStack(ctx context.Context, stackMgr *stackmanager.Manager, name string, profiles []string, services ...string) (*types.Project, error) {
+	project, err := stackMgr.LoadProject(ctx, name, profiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) > 0 {
+		if project, err = stackMgr.EnableServices(name, project, services...); err != nil {
+			return nil, err
+		}
+	}
+	if err := stackMgr.MaterializeSecrets(name, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func parseProfiles(r *http.Request) ([]string, error) {
+	if !r.URL.Query().Has("profiles") {
+		return nil, nil
+	}
+	profiles := []string{}
+	if raw := r.URL.Query().Get("profiles"); raw != "" {
+		for _, p := range strings.Split(raw, ",") {
+			profiles = append(profiles, strings.TrimSpace(p))
+		}
+	}
+	return profiles, stackmanager.ValidateProfiles(profiles)
+}
+
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
 func handleContainers(dockerCtrl *dockercontroller.Controller) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Query().Get("name")
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleActionRejectsInvalidScale(t *testing.T) {
+	handler := handleAction(nil, nil, nil)
+	for _, query := range []string{
+		"name=web&action=scale&service=app&replicas=-1",
+		"name=web&action=scale&service=app&replicas=abc",
+		"name=web&action=scale&replicas=2",
+	} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/api/stack/action?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}